@@ -0,0 +1,108 @@
+// Package clock provides an injectable time source so components that
+// measure elapsed time or tick periodically (ETA calculation, UI refresh,
+// memory stats) can be driven deterministically in tests and simulations.
+package clock
+
+import "time"
+
+// Clock abstracts the time functions used by the pipeline and UI
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker abstracts time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// New returns the real wall-clock implementation
+func New() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) NewTicker(d time.Duration) Ticker       { return &realTicker{time.NewTicker(d)} }
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r *realTicker) C() <-chan time.Time { return r.t.C }
+func (r *realTicker) Stop()               { r.t.Stop() }
+
+// Mock is a manually-advanced clock for deterministic tests.
+// Advance moves time forward and fires any pending After channels and
+// tickers whose deadlines have passed.
+type Mock struct {
+	now     time.Time
+	waiters []*mockWaiter
+	tickers []*mockTicker
+}
+
+type mockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewMock creates a mock clock starting at the given time
+func NewMock(start time.Time) *Mock {
+	return &Mock{now: start}
+}
+
+func (m *Mock) Now() time.Time                  { return m.now }
+func (m *Mock) Since(t time.Time) time.Duration { return m.now.Sub(t) }
+
+func (m *Mock) After(d time.Duration) <-chan time.Time {
+	w := &mockWaiter{deadline: m.now.Add(d), ch: make(chan time.Time, 1)}
+	m.waiters = append(m.waiters, w)
+	return w.ch
+}
+
+func (m *Mock) NewTicker(d time.Duration) Ticker {
+	t := &mockTicker{interval: d, next: m.now.Add(d), ch: make(chan time.Time, 1)}
+	m.tickers = append(m.tickers, t)
+	return t
+}
+
+// Advance moves the mock clock forward, firing due waiters and tickers
+func (m *Mock) Advance(d time.Duration) {
+	m.now = m.now.Add(d)
+
+	remaining := m.waiters[:0]
+	for _, w := range m.waiters {
+		if !m.now.Before(w.deadline) {
+			w.ch <- m.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	m.waiters = remaining
+
+	for _, t := range m.tickers {
+		for !t.stopped && !m.now.Before(t.next) {
+			select {
+			case t.ch <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.interval)
+		}
+	}
+}
+
+type mockTicker struct {
+	interval time.Duration
+	next     time.Time
+	ch       chan time.Time
+	stopped  bool
+}
+
+func (t *mockTicker) C() <-chan time.Time { return t.ch }
+func (t *mockTicker) Stop()               { t.stopped = true }
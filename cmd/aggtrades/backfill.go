@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/clement/aggtrades/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+// newBackfillCmd creates the `backfill` command for patching gap hours in an
+// existing output file
+func newBackfillCmd() *cobra.Command {
+	var inputFile string
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "backfill",
+		Short: "Re-fetch and patch gap hours in an existing output file",
+		Long: `Scans an existing parquet output for zero-filled gap hours, re-downloads
+the raw monthly data covering them, recomputes only those hours, and patches
+the file in place.
+
+Whale columns for patched hours are classified by a detector warmed up on the
+patched month only, not the full rolling window of the original run.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bars, err := output.ReadParquet(inputFile)
+			if err != nil {
+				return fmt.Errorf("read input: %w", err)
+			}
+			if len(bars) == 0 {
+				return fmt.Errorf("no rows in %s", inputFile)
+			}
+
+			// Zero-filled hours are gaps left by FillGaps for large gaps
+			gapMonths := make(map[string][]int) // month -> indices into bars
+			for i, bar := range bars {
+				if bar.Bar.NTrades == 0 {
+					month := bar.Time.Format("2006-01")
+					gapMonths[month] = append(gapMonths[month], i)
+				}
+			}
+
+			if len(gapMonths) == 0 {
+				fmt.Println("No gap hours found")
+				return nil
+			}
+
+			months := make([]string, 0, len(gapMonths))
+			totalGaps := 0
+			for month, indices := range gapMonths {
+				months = append(months, month)
+				totalGaps += len(indices)
+			}
+			sort.Strings(months)
+
+			fmt.Printf("Found %d gap hours across %d months\n", totalGaps, len(months))
+			if dryRun {
+				for _, month := range months {
+					fmt.Printf("  %s: %d gap hours\n", month, len(gapMonths[month]))
+				}
+				return nil
+			}
+
+			tempDir := cfg.TempDir
+			if tempDir == "" {
+				tempDir = fmt.Sprintf("./aggtrades_temp_%s", cfg.Symbol)
+			}
+			if err := os.MkdirAll(tempDir, 0755); err != nil {
+				return fmt.Errorf("create temp dir: %w", err)
+			}
+
+			patched := 0
+			for _, month := range months {
+				n, err := backfillMonth(cmd, bars, month, gapMonths[month], tempDir)
+				if err != nil {
+					fmt.Printf("  %s: %v (skipping)\n", month, err)
+					continue
+				}
+				fmt.Printf("  %s: patched %d/%d gap hours\n", month, n, len(gapMonths[month]))
+				patched += n
+			}
+
+			if patched == 0 {
+				fmt.Println("Nothing patched; file unchanged")
+				return nil
+			}
+
+			// WriteParquet replaces the file atomically, so a crash here
+			// cannot destroy the original
+			if err := output.WriteParquet(bars, inputFile); err != nil {
+				return fmt.Errorf("write output: %w", err)
+			}
+
+			fmt.Printf("Patched %d/%d gap hours in %s\n", patched, totalGaps, inputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Existing parquet output to patch (required)")
+	cmd.MarkFlagRequired("input")
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report gap hours without downloading or patching")
+	return cmd
+}
+
+// backfillMonth downloads one month of raw data, recomputes its hourly bars,
+// and patches the gap hours at the given indices. Returns how many were patched.
+func backfillMonth(cmd *cobra.Command, bars []aggregator.HourlyResult, month string, indices []int, tempDir string) (int, error) {
+	ctx := cmd.Context()
+
+	zipPath := filepath.Join(tempDir, fmt.Sprintf("%s-aggTrades-%s.zip", cfg.Symbol, month))
+	csvPath := filepath.Join(tempDir, fmt.Sprintf("%s-aggTrades-%s.csv", cfg.Symbol, month))
+	defer os.Remove(csvPath)
+
+	if _, err := downloader.DownloadWithRetry(ctx, cfg.MonthURL(month), zipPath, nil); err != nil {
+		return 0, fmt.Errorf("download: %w", err)
+	}
+	if _, err := downloader.ExtractAndRemoveZip(ctx, zipPath, csvPath, nil); err != nil {
+		return 0, fmt.Errorf("extract: %w", err)
+	}
+
+	agg := aggregator.NewAggregator(config.PercentileWindowDays, config.ReservoirSamplesPerDay)
+	if _, err := parser.ParseCSV(csvPath, func(trades []parser.Trade) error {
+		agg.ProcessTrades(trades)
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("parse: %w", err)
+	}
+	agg.Flush()
+
+	recomputed := make(map[time.Time]*aggregator.HourlyBar)
+	for _, bar := range agg.GetAllBars() {
+		recomputed[bar.Time] = bar.Bar
+	}
+
+	patched := 0
+	for _, i := range indices {
+		if bar, ok := recomputed[bars[i].Time]; ok && bar.NTrades > 0 {
+			bars[i].Bar = bar
+			patched++
+		}
+	}
+	return patched, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/clement/aggtrades/internal/parser"
+	"github.com/spf13/cobra"
+)
+
+// newBarsCmd creates the `bars` command: information-driven bars (tick,
+// volume, dollar) built from the local monthly files, as an alternative
+// to the fixed hourly index of the main pipeline
+func newBarsCmd() *cobra.Command {
+	var mode string
+	var threshold float64
+
+	cmd := &cobra.Command{
+		Use:   "bars",
+		Short: "Build tick, volume, or dollar bars from local files",
+		Long: `Builds information-driven bars from the monthly ZIPs/CSVs already in the
+temp directory: a bar closes whenever its accumulated tick count, base
+volume, or dollar volume reaches the threshold, so bars are dense when the
+market is active and sparse when it is quiet. Bars are irregular in time,
+so the output carries a bar_open_time/bar_close_time pair instead of the
+hourly index. Output format follows the file extension (parquet or csv;
+'-' streams CSV to stdout).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			barMode, err := aggregator.ParseBarMode(mode)
+			if err != nil {
+				return err
+			}
+			if threshold <= 0 {
+				return fmt.Errorf("threshold must be positive, got %g", threshold)
+			}
+
+			tempDir := cfg.TempDir
+			if tempDir == "" {
+				tempDir = fmt.Sprintf("./aggtrades_temp_%s", cfg.Symbol)
+			}
+			months, err := localMonths(tempDir)
+			if err != nil {
+				return err
+			}
+			if len(months) == 0 {
+				return fmt.Errorf("no monthly ZIP/CSV files in %s (run download first)", tempDir)
+			}
+
+			outputFile := cfg.OutputFile
+			if outputFile == "" {
+				outputFile = fmt.Sprintf("aggtrades_%s_%sbars.parquet", cfg.Symbol, mode)
+			}
+
+			agg := aggregator.NewEventAggregator(barMode, threshold)
+			var bars []aggregator.EventBar
+			var totalTrades int64
+			for _, month := range months {
+				csvPath := fmt.Sprintf("%s/%s.csv", tempDir, month)
+				if exists, _ := downloader.FileExists(csvPath); !exists {
+					zipPath := fmt.Sprintf("%s/%s.zip", tempDir, month)
+					if _, err := downloader.Extract(context.Background(), zipPath, csvPath, nil); err != nil {
+						return fmt.Errorf("extract %s: %w", month, err)
+					}
+				}
+
+				trades, err := parser.ParseCSV(csvPath, func(batch []parser.Trade) error {
+					agg.ProcessTrades(batch)
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("parse %s: %w", month, err)
+				}
+				totalTrades += trades
+				bars = append(bars, agg.Bars()...)
+			}
+			agg.Flush()
+			bars = append(bars, agg.Bars()...)
+
+			if len(bars) == 0 {
+				return fmt.Errorf("no data produced")
+			}
+
+			if outputFile == "-" {
+				if err := output.WriteEventBarsCSV(bars, os.Stdout); err != nil {
+					return err
+				}
+				return nil
+			}
+			if output.DetectFormat(outputFile) == output.FormatCSV {
+				file, err := os.Create(outputFile)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				if err := output.WriteEventBarsCSV(bars, file); err != nil {
+					return err
+				}
+			} else {
+				if err := output.WriteEventBarsParquet(bars, outputFile); err != nil {
+					return err
+				}
+			}
+
+			fmt.Printf("%d %s bars from %d trades → %s\n", len(bars), mode, totalTrades, outputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	cmd.Flags().StringVarP(&cfg.OutputFile, "output", "o", "", "Output file ('-' streams CSV to stdout)")
+	cmd.Flags().StringVar(&mode, "mode", "dollar", "Bar mode: tick, volume, or dollar")
+	cmd.Flags().Float64Var(&threshold, "threshold", 0, "Bar threshold: trades (tick), base qty (volume), or quote amount (dollar)")
+	return cmd
+}
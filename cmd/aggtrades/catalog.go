@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/binance"
+	"github.com/spf13/cobra"
+)
+
+// estZipBytesPerTrade is a rough compressed-bytes-per-trade ratio for
+// Binance aggTrades ZIPs, used to estimate trade counts from file sizes
+const estZipBytesPerTrade = 20
+
+// catalogMonth is one month's entry in the catalog output
+type catalogMonth struct {
+	Month     string `json:"month"`
+	SizeBytes int64  `json:"size_bytes"`
+	EstTrades int64  `json:"est_trades"`
+}
+
+// catalogEntry is the catalog for one symbol
+type catalogEntry struct {
+	Symbol     string         `json:"symbol"`
+	FirstMonth string         `json:"first_month"`
+	LastMonth  string         `json:"last_month"`
+	TotalBytes int64          `json:"total_bytes"`
+	EstTrades  int64          `json:"est_trades"`
+	Months     []catalogMonth `json:"months"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// newCatalogCmd creates the `catalog` command for listing available data
+// per symbol, to help plan large universe builds
+func newCatalogCmd() *cobra.Command {
+	var symbols string
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "List available months, sizes, and estimated trades per symbol",
+		Long: `Queries the Binance Data Vision listing for each symbol and reports the
+available months with their ZIP sizes and estimated trade counts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var entries []catalogEntry
+			for _, symbol := range strings.Split(symbols, ",") {
+				symbol = strings.TrimSpace(strings.ToUpper(symbol))
+				if symbol == "" {
+					continue
+				}
+				entries = append(entries, catalogSymbol(symbol))
+			}
+			if len(entries) == 0 {
+				return fmt.Errorf("no symbols given")
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(entries)
+			}
+
+			for _, e := range entries {
+				if e.Error != "" {
+					fmt.Printf("%s: %s\n", e.Symbol, e.Error)
+					continue
+				}
+				fmt.Printf("%s: %d months (%s to %s), %.1f GB, ~%dM trades\n",
+					e.Symbol, len(e.Months), e.FirstMonth, e.LastMonth,
+					float64(e.TotalBytes)/1024/1024/1024, e.EstTrades/1_000_000)
+				for _, m := range e.Months {
+					fmt.Printf("  %s  %8.1f MB  ~%dM trades\n",
+						m.Month, float64(m.SizeBytes)/1024/1024, m.EstTrades/1_000_000)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&symbols, "symbols", cfg.Symbol, "Comma-separated symbols to catalog")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Emit JSON instead of a table")
+	return cmd
+}
+
+// catalogSymbol builds the catalog entry for one symbol
+func catalogSymbol(symbol string) catalogEntry {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	entry := catalogEntry{Symbol: symbol}
+
+	dateRange, err := binance.DiscoverDateRange(ctx, symbol)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	entry.FirstMonth = dateRange.FirstMonth
+	entry.LastMonth = dateRange.LastMonth
+
+	months := make([]string, 0, len(dateRange.MonthSizes))
+	for month := range dateRange.MonthSizes {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	for _, month := range months {
+		size := dateRange.MonthSizes[month]
+		entry.Months = append(entry.Months, catalogMonth{
+			Month:     month,
+			SizeBytes: size,
+			EstTrades: size / estZipBytesPerTrade,
+		})
+		entry.TotalBytes += size
+		entry.EstTrades += size / estZipBytesPerTrade
+	}
+
+	return entry
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// newCleanCmd creates the `clean` command for removing stale temp files
+func newCleanCmd() *cobra.Command {
+	var cleanTemp bool
+	var cleanState bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove orphaned temp files and/or the checkpoint",
+		Long: `With --temp, scans the temp directory for stale .tmp files and ZIP/CSV
+artifacts of months that are already checkpointed, and removes them. With
+--state, removes the checkpoint itself so the next run starts from scratch.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cleanTemp && !cleanState {
+				return fmt.Errorf("nothing to clean (use --temp and/or --state)")
+			}
+			if cleanState {
+				mgr := state.NewManager(cfg.CheckpointDir())
+				if dryRun {
+					fmt.Printf("Would remove checkpoint %s\n", mgr.Dir())
+				} else if err := mgr.Clear(); err != nil {
+					return fmt.Errorf("clear checkpoint: %w", err)
+				} else {
+					fmt.Printf("Removed checkpoint %s\n", mgr.Dir())
+				}
+			}
+			if !cleanTemp {
+				return nil
+			}
+
+			tempDir := cfg.TempDir
+			if tempDir == "" {
+				tempDir = fmt.Sprintf("./aggtrades_temp_%s", cfg.Symbol)
+			}
+
+			// Completed months from the checkpoint (if any)
+			var completed []string
+			mgr := state.NewManager(cfg.CheckpointDir())
+			if st, err := mgr.Load(); err == nil && st != nil {
+				completed = st.CompletedMonths
+			}
+
+			report, err := downloader.ScanOrphans(tempDir, completed)
+			if err != nil {
+				return fmt.Errorf("scan temp dir: %w", err)
+			}
+
+			if report.Count() == 0 {
+				fmt.Println("No orphaned files found")
+				return nil
+			}
+
+			for _, path := range report.TmpFiles {
+				fmt.Printf("  stale tmp: %s\n", path)
+			}
+			for _, path := range report.CompletedArtifacts {
+				fmt.Printf("  completed month artifact: %s\n", path)
+			}
+
+			if dryRun {
+				fmt.Printf("Would remove %d files (%.1f MB)\n",
+					report.Count(), float64(report.TotalBytes)/1024/1024)
+				return nil
+			}
+
+			removed := downloader.RemoveOrphans(report)
+			fmt.Printf("Removed %d files (%.1f MB)\n",
+				removed, float64(report.TotalBytes)/1024/1024)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	cmd.Flags().BoolVar(&cleanTemp, "temp", false, "Clean orphaned temp files")
+	cmd.Flags().BoolVar(&cleanState, "state", false, "Remove the checkpoint state")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report without deleting")
+	return cmd
+}
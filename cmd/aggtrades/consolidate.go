@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/binance"
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/memory"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/clement/aggtrades/internal/pipeline"
+	"github.com/clement/aggtrades/internal/ui"
+)
+
+// runConsolidated processes every dollar-stable quote variant of the
+// symbol's base asset (BTCUSDT, BTCBUSD, BTCUSDC, ...) and merges them into
+// one consolidated flow series, with one companion file per venue. Flow
+// fragmented across stables understates true whale activity, so the
+// consolidated series is the primary output.
+//
+// Pairs are processed sequentially, each with its own checkpoint and whale
+// detector: percentile thresholds are per-venue, which keeps whale counts
+// meaningful on the thinner pairs.
+func runConsolidated(msgW io.Writer) error {
+	quote := cfg.QuoteAsset
+	if !cfg.QuoteIsDollar {
+		return fmt.Errorf("--consolidate needs a dollar-stable pair to derive the base asset, got quote %q", quote)
+	}
+	base := strings.TrimSuffix(strings.ToUpper(cfg.Symbol), quote)
+
+	// Find the quote variants that actually have published data
+	type venue struct {
+		symbol string
+		dr     *binance.DateRange
+	}
+	var venues []venue
+	for _, q := range binance.DollarQuoteAssets() {
+		pair := base + q
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		dr, err := binance.DiscoverDateRange(ctx, pair)
+		cancel()
+		if err != nil {
+			continue
+		}
+		venues = append(venues, venue{symbol: pair, dr: dr})
+	}
+	if len(venues) == 0 {
+		return fmt.Errorf("no quote variants of %s found", base)
+	}
+	names := make([]string, len(venues))
+	for i, v := range venues {
+		names[i] = v.symbol
+	}
+	fmt.Fprintf(msgW, "Consolidating %s across %s\n", base, strings.Join(names, ", "))
+
+	// Process each venue with its own config, temp dir, and checkpoint,
+	// clamped to the intersection of its availability and the requested range
+	perVenue := make(map[string][]aggregator.HourlyResult)
+	for _, v := range venues {
+		pcfg := *cfg
+		pcfg.Symbol = v.symbol
+		pcfg.TempDir = "" // default per-symbol temp dir
+		pcfg.MonthSizes = v.dr.MonthSizes
+		if v.dr.FirstMonth > pcfg.StartDate {
+			pcfg.StartDate = v.dr.FirstMonth
+			pcfg.RangeStart = time.Time{}
+		}
+		if v.dr.LastMonth < pcfg.EndDate {
+			pcfg.EndDate = v.dr.LastMonth
+			pcfg.RangeEnd = time.Time{}
+		}
+		if pcfg.StartDate > pcfg.EndDate {
+			fmt.Fprintf(msgW, "  %s: no overlap with requested range, skipping\n", v.symbol)
+			continue
+		}
+		if err := pcfg.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", v.symbol, err)
+		}
+
+		fmt.Fprintf(msgW, "\n=== %s (%s to %s) ===\n", v.symbol, pcfg.StartDate, pcfg.EndDate)
+		bars, err := runVenue(&pcfg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", v.symbol, err)
+		}
+		if len(bars) == 0 {
+			fmt.Fprintf(msgW, "  %s: no data\n", v.symbol)
+			continue
+		}
+
+		// Per-venue companion file
+		venuePath := strings.TrimSuffix(cfg.OutputFile, ".parquet") + "_" + v.symbol + ".parquet"
+		if err := output.WriteParquet(bars, venuePath); err != nil {
+			return fmt.Errorf("write %s: %w", v.symbol, err)
+		}
+		fmt.Fprintf(msgW, "  %s: %d hours → %s\n", v.symbol, len(bars), venuePath)
+		perVenue[v.symbol] = bars
+	}
+	if len(perVenue) == 0 {
+		return fmt.Errorf("no venue produced data")
+	}
+
+	// Merge into the consolidated series
+	consolidated := output.Consolidate(perVenue)
+	if err := output.WriteParquet(consolidated, cfg.OutputFile); err != nil {
+		return fmt.Errorf("write consolidated output: %w", err)
+	}
+	fmt.Fprintf(msgW, "\nConsolidated: %d hours across %d venues → %s\n",
+		len(consolidated), len(perVenue), cfg.OutputFile)
+	return nil
+}
+
+// runVenue runs the pipeline for one pair in plain-log mode and returns its
+// gap-filled, warmup-trimmed bars
+func runVenue(pcfg *config.Config) ([]aggregator.HourlyResult, error) {
+	bars, err := runVenueRaw(pcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	countFill, err := output.ParseGapFill(pcfg.CountFill)
+	if err != nil {
+		return nil, err
+	}
+	bars, _, _ = output.FillGapsMode(bars, config.MaxGapToInterpolate, countFill)
+	bars = output.TrimWarmup(bars, config.WarmupHours)
+	return bars, nil
+}
+
+// runVenueRaw runs the pipeline for one pair in plain-log mode and returns
+// the bars without gap filling or warmup trimming
+func runVenueRaw(pcfg *config.Config) ([]aggregator.HourlyResult, error) {
+	memMgr := memory.NewManager(pcfg.MaxMemoryBytes)
+	defer memMgr.Stop()
+
+	tui := ui.New(false, pcfg.Symbol, pcfg.StartDate, pcfg.EndDate, 0,
+		float64(pcfg.MaxMemoryBytes)/1024/1024/1024)
+	tui.Start()
+	defer tui.Stop()
+
+	p := pipeline.NewPipeline(pcfg, memMgr, tui)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p.UpdateMemoryStats()
+			}
+		}
+	}()
+
+	return p.Run()
+}
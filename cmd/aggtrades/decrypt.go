@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// newDecryptCmd creates the `decrypt` command that opens an output file
+// sealed with --encrypt-key-file
+func newDecryptCmd() *cobra.Command {
+	var inputFile string
+	var outputFile string
+	var keyFile string
+
+	cmd := &cobra.Command{
+		Use:   "decrypt",
+		Short: "Decrypt an output file sealed with --encrypt-key-file",
+		Long: `Opens an output file sealed with AES-256-GCM back into its plain form
+so it can be read by the query/plot/report commands or external tools.
+
+The parquet library offers no parquet modular encryption, so sealing is a
+whole-file envelope: the decrypted result is the original parquet/CSV/JSONL
+file.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !output.IsEncrypted(inputFile) {
+				return fmt.Errorf("%s is not an encrypted aggtrades file", inputFile)
+			}
+			key, err := output.LoadKeyFile(keyFile)
+			if err != nil {
+				return err
+			}
+			if outputFile == "" {
+				if strings.HasSuffix(inputFile, ".enc") {
+					outputFile = strings.TrimSuffix(inputFile, ".enc")
+				} else {
+					outputFile = inputFile + ".dec"
+				}
+			}
+			if err := output.DecryptFile(inputFile, outputFile, key); err != nil {
+				return err
+			}
+			fmt.Printf("Decrypted %s → %s\n", inputFile, outputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Encrypted input file")
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Decrypted output file (default input without .enc, or input + .dec)")
+	cmd.Flags().StringVarP(&keyFile, "key-file", "k", "", "Keyfile (32 raw or 64 hex bytes)")
+	cmd.MarkFlagRequired("input")
+	cmd.MarkFlagRequired("key-file")
+	return cmd
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// columnDiff accumulates the discrepancies of one column across all rows
+type columnDiff struct {
+	rows    int
+	maxDiff float64
+	firstAt time.Time
+}
+
+// newDiffCmd creates the `diff` command for auditing two outputs against
+// each other, e.g. validating a refactor by rerunning the same range and
+// comparing with the previous binary's output
+func newDiffCmd() *cobra.Command {
+	var tolerance float64
+
+	cmd := &cobra.Command{
+		Use:   "diff <a.parquet> <b.parquet>",
+		Short: "Compare two outputs and report discrepancies",
+		Long: `Aligns two parquet outputs on their hourly timestamps and reports hours
+present in only one file, plus per-column value discrepancies beyond the
+tolerance (relative, with an absolute floor of the same magnitude for values
+near zero). Exits non-zero when the files differ.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			barsA, err := output.ReadParquet(args[0])
+			if err != nil {
+				return fmt.Errorf("read %s: %w", args[0], err)
+			}
+			barsB, err := output.ReadParquet(args[1])
+			if err != nil {
+				return fmt.Errorf("read %s: %w", args[1], err)
+			}
+
+			mapA := barsByTime(barsA)
+			mapB := barsByTime(barsB)
+
+			var onlyA, onlyB []time.Time
+			for t := range mapA {
+				if _, ok := mapB[t]; !ok {
+					onlyA = append(onlyA, t)
+				}
+			}
+			for t := range mapB {
+				if _, ok := mapA[t]; !ok {
+					onlyB = append(onlyB, t)
+				}
+			}
+			sort.Slice(onlyA, func(i, j int) bool { return onlyA[i].Before(onlyA[j]) })
+			sort.Slice(onlyB, func(i, j int) bool { return onlyB[i].Before(onlyB[j]) })
+
+			// Compare every registry column on the common hours
+			common := make([]time.Time, 0, len(mapA))
+			for t := range mapA {
+				if _, ok := mapB[t]; ok {
+					common = append(common, t)
+				}
+			}
+			sort.Slice(common, func(i, j int) bool { return common[i].Before(common[j]) })
+
+			diffs := make(map[string]*columnDiff)
+			rowsDiffer := 0
+			for _, t := range common {
+				rowDiffers := false
+				for _, col := range aggregator.Columns {
+					a := toFloat(col.Value(mapA[t]))
+					b := toFloat(col.Value(mapB[t]))
+					if withinTolerance(a, b, tolerance) {
+						continue
+					}
+					rowDiffers = true
+					d := diffs[col.Name]
+					if d == nil {
+						d = &columnDiff{firstAt: t}
+						diffs[col.Name] = d
+					}
+					d.rows++
+					if abs := math.Abs(a - b); abs > d.maxDiff {
+						d.maxDiff = abs
+					}
+				}
+				if rowDiffers {
+					rowsDiffer++
+				}
+			}
+
+			fmt.Printf("%s: %d hours, %s: %d hours, %d common\n",
+				args[0], len(mapA), args[1], len(mapB), len(common))
+			printOnly(args[0], onlyA)
+			printOnly(args[1], onlyB)
+
+			if len(diffs) > 0 {
+				names := make([]string, 0, len(diffs))
+				for name := range diffs {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				fmt.Printf("Columns differing beyond tolerance %g:\n", tolerance)
+				for _, name := range names {
+					d := diffs[name]
+					fmt.Printf("  %-28s %6d rows, max abs diff %.6g, first at %s\n",
+						name, d.rows, d.maxDiff, d.firstAt.Format("2006-01-02 15:04"))
+				}
+			}
+
+			if len(onlyA) == 0 && len(onlyB) == 0 && len(diffs) == 0 {
+				fmt.Println("Outputs match")
+				return nil
+			}
+			return fmt.Errorf("outputs differ: %d rows with value discrepancies, %d/%d unmatched hours",
+				rowsDiffer, len(onlyA), len(onlyB))
+		},
+	}
+
+	cmd.Flags().Float64Var(&tolerance, "tolerance", 1e-9, "Relative tolerance for float column comparisons")
+	return cmd
+}
+
+// barsByTime indexes bars by their hour, keeping the last on duplicates
+func barsByTime(bars []aggregator.HourlyResult) map[time.Time]*aggregator.HourlyBar {
+	m := make(map[time.Time]*aggregator.HourlyBar, len(bars))
+	for _, bar := range bars {
+		m[bar.Time.UTC()] = bar.Bar
+	}
+	return m
+}
+
+// toFloat widens a column value (float64 or int64) for comparison
+func toFloat(v any) float64 {
+	switch x := v.(type) {
+	case float64:
+		return x
+	case int64:
+		return float64(x)
+	default:
+		return math.NaN()
+	}
+}
+
+// withinTolerance compares two values relatively, with an absolute floor so
+// values near zero don't need infinite precision. NaNs compare equal to
+// each other: both sides missing the same way is not a discrepancy.
+func withinTolerance(a, b, tol float64) bool {
+	if math.IsNaN(a) && math.IsNaN(b) {
+		return true
+	}
+	return math.Abs(a-b) <= tol*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+// printOnly reports hours present in only one of the files
+func printOnly(name string, times []time.Time) {
+	if len(times) == 0 {
+		return
+	}
+	fmt.Printf("Only in %s: %d hours (%s → %s)\n", name, len(times),
+		times[0].Format("2006-01-02 15:04"), times[len(times)-1].Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/spf13/cobra"
+)
+
+// newDownloadCmd creates the `download` command: fetch the raw monthly ZIPs
+// for a range into the temp directory without extracting or aggregating,
+// so the network-bound phase can run separately from `process`
+func newDownloadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "download",
+		Short: "Fetch monthly ZIPs only, without processing",
+		Long: `Downloads the raw monthly aggTrades ZIPs for the requested range into the
+temp directory and stops. Months already present are skipped, so the command
+can be re-run to resume. A later full run or the process command picks the
+files up instead of re-downloading them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := resolveDateRange(cmd); err != nil {
+				return fmt.Errorf("failed to resolve date range: %w", err)
+			}
+			if err := cfg.Validate(); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+
+			months, err := cfg.GenerateMonths()
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(cfg.TempDir, 0755); err != nil {
+				return fmt.Errorf("create temp dir: %w", err)
+			}
+
+			var fetched, skipped, missing int
+			var bytes int64
+			for _, month := range months {
+				zipPath := cfg.MonthZipPath(month)
+				if exists, _ := downloader.FileExists(zipPath); exists {
+					skipped++
+					continue
+				}
+
+				size, err := downloadMonthZip(cmd, month, zipPath)
+				if errors.Is(err, downloader.ErrNotFound) {
+					fmt.Printf("  %s: not published\n", month)
+					missing++
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %w", month, err)
+				}
+				fmt.Printf("  %s: %.1f MB\n", month, float64(size)/1024/1024)
+				fetched++
+				bytes += size
+			}
+
+			fmt.Printf("Downloaded %d months (%.2f GB) to %s (%d already present, %d missing)\n",
+				fetched, float64(bytes)/1024/1024/1024, cfg.TempDir, skipped, missing)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.StartDate, "start", "", "Start date (YYYY-MM)")
+	cmd.Flags().StringVar(&cfg.EndDate, "end", "", "End date (YYYY-MM)")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	return cmd
+}
+
+// downloadMonthZip downloads one month's ZIP, trying the known filename
+// variants before declaring the month missing (same order as the pipeline)
+func downloadMonthZip(cmd *cobra.Command, month, zipPath string) (int64, error) {
+	var size int64
+	var err error
+	for _, variant := range cfg.MonthURLVariants(month) {
+		size, err = downloader.DownloadWithRetry(cmd.Context(), variant, zipPath, nil)
+		if err == nil || !errors.Is(err, downloader.ErrNotFound) {
+			return size, err
+		}
+	}
+	return 0, err
+}
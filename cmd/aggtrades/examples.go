@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// examplesText is the `examples` help topic: common end-to-end invocations,
+// roughly in the order a new user needs them
+const examplesText = `Common invocations:
+
+  Full BTCUSDT history to parquet (resumable; re-run to continue):
+    aggtrades -s BTCUSDT
+
+  The last three years, anchored at the latest published month:
+    aggtrades -s ETHUSDT --lookback 36m
+
+  A fixed range with whale rank columns and daily/monthly rollups:
+    aggtrades -s BTCUSDT --start 2021-01 --end 2023-12 --whale-ranks --rollups
+
+  Stream a column subset as CSV into another tool:
+    aggtrades -s BTCUSDT --start 2024-01 -o - --format csv --columns time,close_price,n_trades
+
+  Several symbols plus a wide matrix aligned on a shared hourly index:
+    aggtrades -s BTCUSDT,ETHUSDT,SOLUSDT --wide --process-workers 3
+
+  Split the network and compute phases:
+    aggtrades download -s BTCUSDT --start 2023-01
+    aggtrades process -s BTCUSDT
+
+  Keep an existing output current as new months are published:
+    aggtrades update -s BTCUSDT -i aggtrades_BTCUSDT_2020-01_2025-12.parquet
+
+  Re-export a finished run with different columns, no recompute:
+    aggtrades export -s BTCUSDT --columns time,buy_vol,sell_vol -o flows.csv
+
+  Check progress of a long run from another terminal:
+    aggtrades status -s BTCUSDT
+
+Run 'aggtrades help <command>' for the full flag reference.`
+
+// newExamplesCmd creates the `examples` help topic
+func newExamplesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "examples",
+		Short: "Show common end-to-end invocations",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(examplesText)
+		},
+	}
+}
+
+// registerSymbolCompletion wires dynamic --symbol completion into every
+// command that has the flag. Suggestions come from the temp directories of
+// previous runs in the working directory — the local equivalent of a
+// discovery cache — so completion works offline and only offers symbols the
+// user has actually worked with.
+func registerSymbolCompletion(root *cobra.Command) {
+	complete := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return localSymbols(), cobra.ShellCompDirectiveNoFileComp
+	}
+
+	cmds := append([]*cobra.Command{root}, root.Commands()...)
+	for _, cmd := range cmds {
+		if cmd.Flags().Lookup("symbol") != nil {
+			cmd.RegisterFlagCompletionFunc("symbol", complete)
+		}
+		if cmd.PersistentFlags().Lookup("symbol") != nil {
+			cmd.RegisterFlagCompletionFunc("symbol", complete)
+		}
+	}
+}
+
+// localSymbols lists the symbols with an aggtrades_temp_<symbol> directory
+// in the working directory
+func localSymbols() []string {
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		return nil
+	}
+
+	var symbols []string
+	for _, entry := range entries {
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), "aggtrades_temp_") {
+			if symbol := strings.TrimPrefix(entry.Name(), "aggtrades_temp_"); symbol != "" {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd creates the `export` command: rewrite the checkpointed bars
+// into a target format without re-running the pipeline, so a finished run
+// can be re-exported with different columns or formats
+func newExportCmd() *cobra.Command {
+	var raw bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Rewrite checkpointed bars to a target format",
+		Long: `Loads the hourly bars from the checkpoint of a previous run and writes
+them out again in the requested format and column subset, without
+downloading or aggregating anything. By default the bars get the same
+post-processing as a normal run (gap filling, warmup trim); --raw exports
+them exactly as checkpointed.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := state.NewManager(cfg.CheckpointDir())
+			st, err := mgr.Load()
+			if err != nil {
+				return fmt.Errorf("load state: %w", err)
+			}
+			if st == nil || len(st.CompletedMonths) == 0 {
+				return fmt.Errorf("no completed months in %s", mgr.Dir())
+			}
+
+			bars, err := state.LoadAllBars(mgr.BarsDir(), st.CompletedMonths)
+			if err != nil {
+				return fmt.Errorf("load bars: %w", err)
+			}
+			if len(bars) == 0 {
+				return fmt.Errorf("checkpoint has no bars")
+			}
+
+			if !raw {
+				countFill, err := output.ParseGapFill(cfg.CountFill)
+				if err != nil {
+					return fmt.Errorf("invalid --count-fill: %w", err)
+				}
+				bars, _, _ = output.FillGapsMode(bars, config.MaxGapToInterpolate, countFill)
+				bars = output.TrimWarmup(bars, config.WarmupHours)
+			}
+
+			columns := output.ColumnNames()
+			if cfg.Columns != "" {
+				columns, err = output.ParseColumns(cfg.Columns)
+				if err != nil {
+					return fmt.Errorf("invalid --columns: %w", err)
+				}
+			}
+
+			months := st.CompletedMonths
+			if cfg.OutputFile == "" {
+				cfg.OutputFile = fmt.Sprintf("aggtrades_%s_%s_%s.parquet",
+					cfg.Symbol, months[0], months[len(months)-1])
+			}
+			streamStdout := cfg.OutputFile == "-"
+
+			format := output.DetectFormat(cfg.OutputFile)
+			if cfg.Format != "" {
+				format, err = output.ParseFormat(cfg.Format)
+				if err != nil {
+					return err
+				}
+			}
+			if streamStdout && format == output.FormatParquet {
+				return fmt.Errorf("--output - requires --format csv or jsonl (parquet needs a seekable file)")
+			}
+
+			if err := writeBars(bars, format, columns, nil, streamStdout); err != nil {
+				return err
+			}
+			if !streamStdout {
+				fmt.Printf("%d hours → %s\n", len(bars), cfg.OutputFile)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	cmd.Flags().StringVarP(&cfg.OutputFile, "output", "o", "", "Output file (use '-' to stream CSV/JSONL to stdout)")
+	cmd.Flags().StringVar(&cfg.Format, "format", "", "Output format: parquet, csv, or jsonl (default from extension)")
+	cmd.Flags().StringVar(&cfg.Columns, "columns", "", "Comma-separated column subset to export")
+	cmd.Flags().BoolVar(&raw, "raw", false, "Export bars exactly as checkpointed, without gap filling or warmup trim")
+	return cmd
+}
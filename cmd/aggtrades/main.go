@@ -3,22 +3,37 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/alert"
 	"github.com/clement/aggtrades/internal/binance"
 	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/clement/aggtrades/internal/events"
 	"github.com/clement/aggtrades/internal/memory"
 	"github.com/clement/aggtrades/internal/output"
 	"github.com/clement/aggtrades/internal/pipeline"
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/clement/aggtrades/internal/telemetry"
 	"github.com/clement/aggtrades/internal/ui"
+	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 )
 
 var (
 	cfg = config.New()
+
+	// serveAddr enables the runtime control REST API when set
+	serveAddr string
 )
 
 func main() {
@@ -35,11 +50,86 @@ If --end is not specified, downloads until the last available data for the symbo
 
 	// Flags
 	rootCmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
-	rootCmd.Flags().StringVar(&cfg.StartDate, "start", "", "Start date (YYYY-MM), defaults to first available")
-	rootCmd.Flags().StringVar(&cfg.EndDate, "end", "", "End date (YYYY-MM), defaults to last available")
+	rootCmd.Flags().StringVar(&cfg.StartDate, "start", "", "Start date (YYYY-MM, YYYY-MM-DD, YYYY, or -Nm), defaults to first available")
+	rootCmd.Flags().StringVar(&cfg.EndDate, "end", "", "End date (YYYY-MM, YYYY-MM-DD, YYYY, -Nm, or latest), defaults to last available")
 	rootCmd.Flags().IntVar(&cfg.MaxMemoryPct, "max-memory", cfg.MaxMemoryPct, "Maximum memory usage percentage")
 	rootCmd.Flags().IntVar(&cfg.DownloadWorkers, "download-workers", cfg.DownloadWorkers, "Number of download workers")
+	rootCmd.Flags().IntVar(&cfg.Prefetch, "prefetch", cfg.Prefetch, "Maximum downloaded-but-unprocessed months in flight")
+	rootCmd.Flags().IntVar(&cfg.ProcessWorkers, "process-workers", cfg.ProcessWorkers, "Concurrent symbols in a multi-symbol run (memory budget is split between them)")
+	rootCmd.Flags().StringVar(&cfg.ParseBackend, "parse-backend", "", "CSV parse backend: bufio (default) or mmap (parse in place, faster on NVMe)")
+	rootCmd.Flags().IntVar(&cfg.ParseBatchSize, "parse-batch-size", 0, "Trades per parser batch (0 uses the built-in default)")
+	rootCmd.Flags().IntVar(&cfg.FlushAfterHours, "flush-after", 0, "Finalize bars this many hours behind the trade stream during processing (0 waits for checkpoints)")
+	rootCmd.Flags().StringVar(&cfg.WhalePercentiles, "whale-percentiles", "", "Extra whale percentile tiers (comma-separated, e.g. 95,99.5); adds a column group per tier")
+	rootCmd.Flags().IntVar(&cfg.CompareWindowDays, "compare-window", 0, "Run a second whale detector with this rolling window (days) alongside the primary; adds _w<days> columns")
+	rootCmd.Flags().BoolVar(&cfg.WhaleNotional, "whale-notional", false, "Compute whale thresholds over USD notional (price*qty) instead of raw quantity")
+	rootCmd.Flags().StringVar(&cfg.LatePolicy, "late-policy", "", "Handling of trades arriving after their bar finalized: drop (default, bars immutable) or revise (bars corrected in place)")
+	rootCmd.Flags().IntVar(&cfg.LateGraceHours, "late-grace", 0, "Hold bars open this many hours behind the trade stream before finalizing, giving late data time to arrive")
 	rootCmd.Flags().BoolVar(&cfg.NoTUI, "no-tui", cfg.NoTUI, "Disable TUI (use plain logs)")
+	rootCmd.Flags().StringVar(&cfg.MaxTempSize, "max-temp-size", "", "Maximum temp directory size (e.g. 100GB, default unlimited)")
+	rootCmd.Flags().StringVar(&cfg.MaxTotalDownload, "max-total-download", "", "Abort if total network download exceeds this size (e.g. 50GB, default unlimited)")
+	rootCmd.Flags().BoolVar(&cfg.Wide, "wide", false, "With multiple symbols, also write a wide-format matrix aligned on a shared hourly index")
+	rootCmd.Flags().BoolVar(&cfg.Consolidate, "consolidate", false, "Merge all dollar-stable quote variants of the base asset into one consolidated series")
+	rootCmd.Flags().BoolVar(&cfg.Rollups, "rollups", false, "Also write daily and monthly rollup companion files")
+	rootCmd.Flags().StringVar(&cfg.Filter, "filter", "", "Per-trade filter expression (e.g. 'qty >= 0.001 && price > 0')")
+	rootCmd.Flags().StringVarP(&cfg.OutputFile, "output", "o", "", "Output file (use '-' to stream CSV/JSONL to stdout)")
+	rootCmd.Flags().StringVar(&cfg.Format, "format", "", "Output format: parquet, csv, or jsonl (default from extension)")
+	rootCmd.Flags().StringVar(&cfg.Columns, "columns", "", "Comma-separated subset of output columns to write (default all)")
+	rootCmd.Flags().StringVar(&cfg.Transforms, "transform", "", "Derived columns as kind:column specs (log1p, zscore[:window], ratio; comma-separated)")
+	rootCmd.Flags().StringVar(&cfg.LPMeasurement, "lp-measurement", "aggtrades", "Line-protocol measurement name for --format lp")
+	rootCmd.Flags().StringVar(&cfg.LPTags, "lp-tags", "", "Extra line-protocol tags as 'key=value,key=value'")
+	rootCmd.Flags().IntVar(&cfg.TopKTrades, "top-trades", 0, "Track the K largest trades per hour in a companion file (0 = disabled)")
+	rootCmd.Flags().BoolVar(&cfg.WhaleRanks, "whale-ranks", false, "Track per-trade percentile ranks for the whale_rank_vw_mean/whale_rank_max columns")
+	rootCmd.Flags().BoolVar(&cfg.StitchOrders, "stitch-orders", false, "Stitch split orders into logical orders before whale classification")
+	rootCmd.Flags().BoolVar(&cfg.PreciseSums, "precise-sums", false, "Accumulate volume sums with Kahan compensation and report naive-sum drift")
+	rootCmd.Flags().BoolVar(&cfg.RestTopUp, "rest-topup", false, "Top up the tail with recent hours from the REST aggTrades endpoint (source_rest=1)")
+	rootCmd.Flags().StringArrayVar(&cfg.AuthHeaders, "auth-header", nil, "Inject a header into downloads from a base URL ('urlprefix=Header: value'); repeatable")
+	rootCmd.Flags().StringVar(&cfg.UserAgent, "user-agent", "", "Custom User-Agent for downloads (default aggtrades/<version>)")
+	rootCmd.Flags().StringVar(&cfg.IPVersion, "ip-version", "", "Force downloads onto one IP family: 4 or 6 (default auto)")
+	rootCmd.Flags().StringVar(&cfg.DNSServer, "dns-server", "", "Custom DNS resolver for downloads (host or host:port)")
+	rootCmd.Flags().DurationVar(&cfg.DNSCacheTTL, "dns-cache-ttl", 0, "Cache download DNS answers for this long (e.g. 5m; default off)")
+	rootCmd.Flags().BoolVar(&cfg.Simulate, "simulate", false, "Run the pipeline on generated synthetic months (no network; requires --start/--end)")
+	rootCmd.Flags().StringVar(&cfg.Lookback, "lookback", "", "Cover the last N months ending at the latest available (e.g. 36m); whale warmup is added and trimmed automatically")
+	rootCmd.Flags().StringVar(&cfg.ExcludeHours, "exclude-hours", "", "Comma-separated time windows to exclude (e.g. '2021-03-15T00:00/2021-03-16T12:00')")
+	rootCmd.Flags().StringVar(&cfg.ExcludeFile, "exclude-file", "", "Calendar file with one exclusion window per line")
+	rootCmd.Flags().BoolVar(&cfg.PruneCheckpoint, "prune-checkpoint-on-success", false, "Delete per-month checkpoint bars after a successful final write (keeps detector state)")
+	rootCmd.Flags().StringVar(&cfg.ProgressFile, "progress-file", "", "Continuously write a JSON progress snapshot here for external monitoring")
+	rootCmd.Flags().StringVar(&cfg.EncryptKeyFile, "encrypt-key-file", "", "Keyfile (32 raw or 64 hex bytes) to seal the output with AES-256-GCM")
+	rootCmd.Flags().StringVar(&cfg.CountFill, "count-fill", "interpolate", "Gap fill for count columns: interpolate or zero (counts stay 0 in gaps)")
+	rootCmd.Flags().StringArrayVar(&cfg.Alerts, "alert", nil, "Alert rule evaluated per finalized bar (e.g. 'whale_buy_vol_p999 > 500', repeatable)")
+	rootCmd.Flags().StringVar(&cfg.AlertFile, "alert-file", "", "File receiving alert matches as JSON lines (default alerts.jsonl)")
+	rootCmd.Flags().StringVar(&cfg.AlertWebhook, "alert-webhook", "", "URL receiving each alert match as a JSON POST")
+	rootCmd.Flags().StringVar(&cfg.PublishURL, "publish", "", "NATS server URL receiving each finalized bar as a JSON event (e.g. nats://localhost:4222)")
+	rootCmd.Flags().StringVar(&cfg.PublishSubject, "publish-subject", "aggtrades.bars", "NATS subject for published bar events")
+	rootCmd.Flags().BoolVar(&cfg.KeepBackup, "keep-bak", false, "Keep the previous output file as <output>.bak when overwriting")
+	rootCmd.Flags().StringVar(&cfg.LockFile, "lockfile", "", "Pin archive checksums: verify against this file when it exists, create it after a successful run when it does not")
+	rootCmd.Flags().StringVar(&serveAddr, "serve", "", "Listen address for the runtime control REST API (e.g. :8080)")
+	rootCmd.Flags().StringVar(&cfg.GoMemLimit, "gomemlimit", "", "Override GOMEMLIMIT (size like 8GB, or 'off'; default derived from --max-memory)")
+	rootCmd.Flags().IntVar(&cfg.GoGC, "gogc", 0, "Override GOGC percentage (default runtime setting)")
+	rootCmd.Flags().StringVar(&cfg.TelemetryEndpoint, "telemetry-endpoint", "", "URL receiving anonymous run summaries (opt-in, off by default)")
+
+	// Subcommands
+	rootCmd.AddCommand(newDownloadCmd())
+	rootCmd.AddCommand(newProcessCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newBarsCmd())
+	rootCmd.AddCommand(newSoakCmd())
+	rootCmd.AddCommand(newStatusCmd())
+	rootCmd.AddCommand(newVersionCmd())
+	rootCmd.AddCommand(newUpdateCmd())
+	rootCmd.AddCommand(newStateCmd())
+	rootCmd.AddCommand(newBackfillCmd())
+	rootCmd.AddCommand(newCleanCmd())
+	rootCmd.AddCommand(newCatalogCmd())
+	rootCmd.AddCommand(newQueryCmd())
+	rootCmd.AddCommand(newPlotCmd())
+	rootCmd.AddCommand(newReportCmd())
+	rootCmd.AddCommand(newVerifyManifestCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newDecryptCmd())
+	rootCmd.AddCommand(newExamplesCmd())
+
+	// Dynamic --symbol completion for every command that takes it
+	registerSymbolCompletion(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -49,6 +139,21 @@ If --end is not specified, downloads until the last available data for the symbo
 func run(cmd *cobra.Command, args []string) error {
 	startTime := time.Now()
 
+	// When streaming bars to stdout, all human-readable output must go to
+	// stderr so the data stream stays clean
+	streamStdout := cfg.OutputFile == "-"
+	msgW := io.Writer(os.Stdout)
+	if streamStdout {
+		msgW = os.Stderr
+		cfg.NoTUI = true
+		pterm.SetDefaultOutput(os.Stderr)
+	}
+
+	// Multiple symbols: run each sequentially, optionally with a wide matrix
+	if strings.Contains(cfg.Symbol, ",") {
+		return runMatrix(cmd, msgW)
+	}
+
 	// Resolve dynamic date range if needed
 	if err := resolveDateRange(cmd); err != nil {
 		return fmt.Errorf("failed to resolve date range: %w", err)
@@ -59,14 +164,115 @@ func run(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("configuration error: %w", err)
 	}
 
+	// Validate the column projection before doing any work
+	var columns []string
+	if cfg.Columns != "" {
+		cols, err := output.ParseColumns(cfg.Columns)
+		if err != nil {
+			return fmt.Errorf("invalid --columns: %w", err)
+		}
+		columns = cols
+	}
+
+	// Validate the transform specs before doing any work
+	transforms, err := output.ParseTransforms(cfg.Transforms)
+	if err != nil {
+		return fmt.Errorf("invalid --transform: %w", err)
+	}
+
+	countFill, err := output.ParseGapFill(cfg.CountFill)
+	if err != nil {
+		return fmt.Errorf("invalid --count-fill: %w", err)
+	}
+
+	// Resolve the output format
+	format := output.DetectFormat(cfg.OutputFile)
+	if cfg.Format != "" {
+		var err error
+		format, err = output.ParseFormat(cfg.Format)
+		if err != nil {
+			return err
+		}
+	}
+	if streamStdout {
+		if format == output.FormatParquet {
+			return fmt.Errorf("--output - requires --format csv or jsonl (parquet needs a seekable file)")
+		}
+		if cfg.Rollups || cfg.TopKTrades > 0 {
+			return fmt.Errorf("--rollups and --top-trades need a file output, not stdout")
+		}
+	}
+	if _, err := output.ParseTags(cfg.LPTags); err != nil {
+		return fmt.Errorf("invalid --lp-tags: %w", err)
+	}
+	if (output.IsSQLite(cfg.OutputFile) || output.IsDelta(cfg.OutputFile)) && (cfg.Rollups || cfg.TopKTrades > 0) {
+		return fmt.Errorf("--rollups and --top-trades write companion files and need a file output")
+	}
+	if len(transforms) > 0 && (output.IsSQLite(cfg.OutputFile) || output.IsDelta(cfg.OutputFile) || format == output.FormatLineProtocol) {
+		return fmt.Errorf("--transform is only supported for parquet, csv, and jsonl outputs")
+	}
+
+	// Validate the encryption key before doing any work; encryption seals
+	// the output file in place, so it needs a plain file output
+	var encryptKey []byte
+	if cfg.EncryptKeyFile != "" {
+		if streamStdout || output.IsSQLite(cfg.OutputFile) || output.IsDelta(cfg.OutputFile) {
+			return fmt.Errorf("--encrypt-key-file needs a plain file output")
+		}
+		encryptKey, err = output.LoadKeyFile(cfg.EncryptKeyFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Install auth headers for private mirrors before any download starts
+	if len(cfg.AuthHeaders) > 0 {
+		rules, err := downloader.ParseAuthRules(cfg.AuthHeaders)
+		if err != nil {
+			return fmt.Errorf("invalid --auth-header: %w", err)
+		}
+		downloader.SetAuthRules(rules)
+	}
+
+	// Dialer tuning (IP family, resolver, DNS cache) for flaky CDN routes
+	if err := downloader.SetDialOptions(cfg.IPVersion, cfg.DNSServer, cfg.DNSCacheTTL); err != nil {
+		return err
+	}
+
+	// Identify the tool (and release) to proxies and the archive CDN
+	ua := cfg.UserAgent
+	if ua == "" {
+		ua = "aggtrades/" + version
+	}
+	downloader.SetUserAgent(ua)
+
+	// Let the Go runtime cooperate with the reservation-based memory
+	// manager instead of fighting it
+	if err := applyRuntimeTuning(); err != nil {
+		return err
+	}
+
 	// Get months to process
 	months, err := cfg.GenerateMonths()
 	if err != nil {
 		return fmt.Errorf("generate months: %w", err)
 	}
 
-	fmt.Printf("AggTradeTool - %s\n", cfg.String())
-	fmt.Printf("Months to process: %d (%s to %s)\n\n", len(months), cfg.StartDate, cfg.EndDate)
+	// Warn when the quote asset is not a dollar stable: the *_usd columns
+	// are then denominated in the quote asset, not dollars
+	if cfg.QuoteAsset == "" {
+		fmt.Fprintf(msgW, "Warning: could not detect quote asset for %s; buy_vol_usd/sell_vol_usd are in quote units\n", cfg.Symbol)
+	} else if !cfg.QuoteIsDollar {
+		fmt.Fprintf(msgW, "Warning: quote asset %s is not a dollar stable; buy_vol_usd/sell_vol_usd are in %s\n", cfg.QuoteAsset, cfg.QuoteAsset)
+	}
+
+	// Consolidation mode runs one pipeline per quote variant and merges
+	if cfg.Consolidate {
+		return runConsolidated(msgW)
+	}
+
+	fmt.Fprintf(msgW, "AggTradeTool - %s\n", cfg.String())
+	fmt.Fprintf(msgW, "Months to process: %d (%s to %s)\n\n", len(months), cfg.StartDate, cfg.EndDate)
 
 	// Initialize memory manager
 	memMgr := memory.NewManager(cfg.MaxMemoryBytes)
@@ -84,9 +290,65 @@ func run(cmd *cobra.Command, args []string) error {
 	tui.Start()
 	defer tui.Stop()
 
+	// Pollable progress snapshot, independent of the TUI
+	if cfg.ProgressFile != "" {
+		tui.StartProgressWriter(cfg.ProgressFile, 2*time.Second)
+	}
+
 	// Initialize pipeline
 	p := pipeline.NewPipeline(cfg, memMgr, tui)
 
+	// Optional alert rules, evaluated as bars finalize
+	var alerts *alert.Engine
+	if len(cfg.Alerts) > 0 {
+		alertFile := cfg.AlertFile
+		if alertFile == "" && cfg.AlertWebhook == "" {
+			alertFile = "alerts.jsonl"
+		}
+		alerts, err = alert.NewEngine(cfg.Alerts, alertFile, cfg.AlertWebhook)
+		if err != nil {
+			return err
+		}
+		defer alerts.Close()
+		p.SetAlerts(alerts)
+	}
+
+	// Pinned archive checksums for exact reproducibility of published
+	// datasets: verify when the lockfile exists, create it on success below
+	var lock *state.Lockfile
+	if cfg.LockFile != "" {
+		lock, err = state.LoadLockfile(cfg.LockFile)
+		if err != nil {
+			return fmt.Errorf("read lockfile: %w", err)
+		}
+		if lock != nil {
+			p.SetLockfile(lock)
+			tui.LogInfo("Lockfile: verifying against %d pinned archive checksums", len(lock.Sources))
+		}
+	}
+
+	// Optional NATS event publisher for finalized bars
+	var publisher *events.Publisher
+	if cfg.PublishURL != "" {
+		publisher, err = events.NewPublisher(cfg.PublishURL, cfg.PublishSubject, cfg.Symbol)
+		if err != nil {
+			return fmt.Errorf("event publisher: %w", err)
+		}
+		defer publisher.Close()
+		publisher.SetMutable(cfg.LatePolicy == "revise")
+		p.SetPublisher(publisher)
+	}
+
+	// Optional control API for pause/resume/cancel and live worker counts
+	if serveAddr != "" {
+		srv, err := startControlServer(serveAddr, p)
+		if err != nil {
+			return fmt.Errorf("control server: %w", err)
+		}
+		defer srv.Close()
+		fmt.Fprintf(msgW, "Control API listening on %s\n", serveAddr)
+	}
+
 	// Context for graceful shutdown of background goroutines
 	ctx, cancelStats := context.WithCancel(context.Background())
 	defer cancelStats()
@@ -117,6 +379,7 @@ func run(cmd *cobra.Command, args []string) error {
 	// Run pipeline
 	bars, err := p.Run()
 	if err != nil {
+		postTelemetry(p, len(months), 0, 0, time.Since(startTime), "pipeline")
 		return fmt.Errorf("pipeline error: %w", err)
 	}
 
@@ -125,6 +388,17 @@ func run(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	// Optionally extend the tail to near-real-time from the REST endpoint
+	if cfg.RestTopUp {
+		extra, fetched, err := p.TopUpFromREST(bars[len(bars)-1].Time)
+		if err != nil {
+			tui.LogWarning("REST top-up failed, output ends at the dump files: %v", err)
+		} else if len(extra) > 0 {
+			bars = append(bars, extra...)
+			tui.LogInfo("REST top-up: %d hours from %d trades", len(extra), fetched)
+		}
+	}
+
 	// Validate data
 	tui.LogInfo("Validating data...")
 	validation := output.Validate(bars)
@@ -136,7 +410,7 @@ func run(cmd *cobra.Command, args []string) error {
 
 	// Fill gaps
 	tui.LogInfo("Filling gaps...")
-	bars, smallFilled, largeNaN := output.FillGaps(bars, config.MaxGapToInterpolate)
+	bars, smallFilled, largeNaN := output.FillGapsMode(bars, config.MaxGapToInterpolate, countFill)
 	tui.LogInfo("Gaps: %d interpolated, %d left as NaN", smallFilled, largeNaN)
 
 	// Trim warmup period
@@ -144,16 +418,149 @@ func run(cmd *cobra.Command, args []string) error {
 	bars = output.TrimWarmup(bars, config.WarmupHours)
 	tui.LogInfo("Trimmed warmup: %d hours removed", originalLen-len(bars))
 
+	// Lookback runs: drop the history that only warmed the whale window
+	if !cfg.LookbackStart.IsZero() {
+		before := len(bars)
+		bars = output.TrimBefore(bars, cfg.LookbackStart)
+		tui.LogInfo("Lookback: trimmed %d whale-warmup hours before %s", before-len(bars), cfg.LookbackStart.Format("2006-01"))
+	}
+
+	// Derived transform columns over the final series
+	var extras []output.ExtraColumn
+	if len(transforms) > 0 {
+		extras = output.ComputeTransforms(bars, transforms)
+		tui.LogInfo("Transforms: %d derived columns", len(extras))
+	}
+
 	// Calculate totals
 	var totalTrades int64
 	for _, bar := range bars {
 		totalTrades += bar.Bar.NTrades
 	}
 
-	// Write parquet
-	tui.LogInfo("Writing parquet...")
-	if err := output.WriteParquet(bars, cfg.OutputFile); err != nil {
-		return fmt.Errorf("write parquet: %w", err)
+	// Keep the previous output as .bak before the atomic replace
+	// (SQLite and Delta outputs version in place instead of being replaced)
+	if cfg.KeepBackup && !streamStdout && !output.IsSQLite(cfg.OutputFile) && !output.IsDelta(cfg.OutputFile) {
+		if _, err := os.Stat(cfg.OutputFile); err == nil {
+			if err := os.Rename(cfg.OutputFile, cfg.OutputFile+".bak"); err != nil {
+				tui.LogWarning("Failed to keep backup: %v", err)
+			}
+		}
+	}
+
+	// Write the output
+	colNames := columns
+	if colNames == nil {
+		colNames = output.ColumnNames()
+	}
+	if err := writeBars(bars, format, colNames, extras, streamStdout); err != nil {
+		return err
+	}
+
+	// Seal the output in place before hashing it into the manifest, so the
+	// plaintext never persists and the manifest describes the stored file
+	if encryptKey != nil {
+		if err := output.EncryptFile(cfg.OutputFile, cfg.OutputFile, encryptKey); err != nil {
+			return fmt.Errorf("encrypt output: %w", err)
+		}
+		tui.LogInfo("Output sealed with AES-256-GCM (decrypt with 'aggtrades decrypt')")
+	}
+
+	// Pin the observed archive checksums on the first locked run
+	if cfg.LockFile != "" && lock == nil {
+		lf := &state.Lockfile{Symbol: cfg.Symbol, CreatedAt: time.Now().UTC(), Sources: p.Sources()}
+		if err := state.WriteLockfile(cfg.LockFile, lf); err != nil {
+			tui.LogWarning("Failed to write lockfile: %v", err)
+		} else {
+			tui.LogInfo("Lockfile: pinned %d archive checksums to %s", len(lf.Sources), cfg.LockFile)
+		}
+	}
+
+	// Reproducibility manifest beside plain file outputs
+	if !streamStdout && !output.IsSQLite(cfg.OutputFile) && !output.IsDelta(cfg.OutputFile) {
+		if err := writeRunManifest(p, len(bars)); err != nil {
+			tui.LogWarning("Failed to write manifest: %v", err)
+		} else {
+			tui.LogInfo("Manifest: %s", manifestPath(cfg.OutputFile))
+		}
+	}
+
+	// Optional top-K largest trades companion file
+	if cfg.TopKTrades > 0 {
+		topTrades, err := p.LoadTopTrades()
+		if err != nil {
+			tui.LogWarning("Failed to load top trades: %v", err)
+		} else {
+			topPath := strings.TrimSuffix(cfg.OutputFile, ".parquet") + "_toptrades.jsonl"
+			if err := output.WriteTopTrades(topTrades, topPath); err != nil {
+				return fmt.Errorf("write top trades: %w", err)
+			}
+			tui.LogInfo("Top trades: %d hours → %s", len(topTrades), topPath)
+		}
+	}
+
+	// Optional daily/monthly rollup companion files
+	if cfg.Rollups {
+		daily := output.Rollup(bars, output.RollupDaily)
+		dailyPath := output.RollupPath(cfg.OutputFile, "daily")
+		if err := output.WriteParquet(daily, dailyPath); err != nil {
+			return fmt.Errorf("write daily rollup: %w", err)
+		}
+		tui.LogInfo("Daily rollup: %d bars → %s", len(daily), dailyPath)
+
+		monthly := output.Rollup(bars, output.RollupMonthly)
+		monthlyPath := output.RollupPath(cfg.OutputFile, "monthly")
+		if err := output.WriteParquet(monthly, monthlyPath); err != nil {
+			return fmt.Errorf("write monthly rollup: %w", err)
+		}
+		tui.LogInfo("Monthly rollup: %d bars → %s", len(monthly), monthlyPath)
+	}
+
+	// Optionally prune per-month checkpoint bars now that the output is
+	// safely written (detector state stays for future extension runs)
+	if cfg.PruneCheckpoint {
+		mgr := state.NewManager(cfg.CheckpointDir())
+		removed, bytes, err := mgr.PruneBars()
+		if err != nil {
+			tui.LogWarning("Failed to prune checkpoint bars: %v", err)
+		} else if removed > 0 {
+			tui.LogInfo("Pruned %d checkpoint bar files (%.1f MB)", removed, float64(bytes)/1024/1024)
+		}
+	}
+
+	// Event publish summary
+	if publisher != nil {
+		published, pubErrs := publisher.Stats()
+		tui.LogInfo("Events: %d bars published to %s", published, cfg.PublishSubject)
+		if pubErrs > 0 {
+			tui.LogWarning("Events: %d publishes failed", pubErrs)
+		}
+	}
+
+	// Stitching summary: how classification changed vs raw aggTrades
+	if cfg.StitchOrders {
+		orders, up99, up999 := p.StitchStats()
+		tui.LogInfo("Stitching: %d logical orders; %d trades upgraded to P99 whale, %d to P99.9", orders, up99, up999)
+	}
+
+	// Late-data summary: trades that arrived after their bar finalized
+	if dropped, corrections := p.LateStats(); dropped > 0 || corrections > 0 {
+		tui.LogInfo("Late trades: %d dropped, %d bar corrections published", dropped, corrections)
+	}
+
+	// Summation self-check: how far the naive float sums drifted from the
+	// compensated ones
+	if cfg.PreciseSums {
+		tui.LogInfo("Precise sums: max relative drift of naive summation %.3g", p.MaxSumDrift())
+	}
+
+	// Alert summary
+	if alerts != nil {
+		matches, sinkErrs := alerts.Stats()
+		tui.LogInfo("Alerts: %d matches", matches)
+		if sinkErrs > 0 {
+			tui.LogWarning("Alerts: %d sink writes failed", sinkErrs)
+		}
 	}
 
 	// Stop TUI and print final stats
@@ -161,40 +568,271 @@ func run(cmd *cobra.Command, args []string) error {
 	duration := time.Since(startTime)
 	tui.PrintFinalStats(len(bars), totalTrades, duration, cfg.OutputFile)
 
+	// Bandwidth summary, and a cost sanity check for metered connections
+	if downloaded, _ := p.Downloaded(); downloaded > 0 {
+		fmt.Fprintf(msgW, "Downloaded: %.2f GB over the network\n", float64(downloaded)/1024/1024/1024)
+	}
+
+	// Bottleneck report: where the time went, and what to tune
+	report := p.TimingReport()
+	if report.Total() > 0 {
+		fmt.Fprintf(msgW, "Time breakdown: %s\n", report)
+		if hint := report.Suggestion(); hint != "" {
+			fmt.Fprintf(msgW, "Tuning hint: %s\n", hint)
+		}
+	}
+
+	postTelemetry(p, len(months), len(bars), totalTrades, duration, "")
+
+	// GC behavior summary, useful when tuning --gomemlimit/--gogc
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	fmt.Fprintf(msgW, "GC: %d cycles, %s total pause\n",
+		memStats.NumGC, time.Duration(memStats.PauseTotalNs).Round(time.Millisecond))
+
+	return nil
+}
+
+// postTelemetry sends an opt-in run summary if an endpoint is configured.
+// Best effort: failures only produce a warning.
+func postTelemetry(p *pipeline.Pipeline, months, hours int, trades int64, duration time.Duration, errorClass string) {
+	if cfg.TelemetryEndpoint == "" {
+		return
+	}
+
+	report := p.TimingReport()
+	summary := telemetry.Summary{
+		Symbol:       cfg.Symbol,
+		Months:       months,
+		Hours:        hours,
+		Trades:       trades,
+		DurationSec:  duration.Seconds(),
+		DownloadSec:  report.Download.Seconds(),
+		ExtractSec:   report.Extract.Seconds(),
+		ParseSec:     report.Parse.Seconds(),
+		AggregateSec: report.Aggregate.Seconds(),
+		WriteSec:     report.Write.Seconds(),
+		ErrorClass:   errorClass,
+	}
+	if duration > 0 {
+		summary.TradesPerSec = float64(trades) / duration.Seconds()
+	}
+
+	if err := telemetry.Post(cfg.TelemetryEndpoint, summary); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: telemetry post failed: %v\n", err)
+	}
+}
+
+// applyRuntimeTuning sets GOMEMLIMIT from the configured memory budget
+// (unless overridden) and applies an optional GOGC override
+func applyRuntimeTuning() error {
+	switch cfg.GoMemLimit {
+	case "off":
+		// Leave the runtime default
+	case "":
+		debug.SetMemoryLimit(cfg.MaxMemoryBytes)
+	default:
+		limit, err := config.ParseSize(cfg.GoMemLimit)
+		if err != nil {
+			return fmt.Errorf("invalid --gomemlimit: %w", err)
+		}
+		debug.SetMemoryLimit(limit)
+	}
+
+	if cfg.GoGC > 0 {
+		debug.SetGCPercent(cfg.GoGC)
+	}
 	return nil
 }
 
+// writeBars writes the final bars in the requested format, either to the
+// configured output file or to stdout
+func writeBars(bars []aggregator.HourlyResult, format output.Format, columns []string, extras []output.ExtraColumn, streamStdout bool) error {
+	if output.IsSQLite(cfg.OutputFile) {
+		meta := output.RunMeta{Symbol: cfg.Symbol, StartDate: cfg.StartDate, EndDate: cfg.EndDate, Version: version}
+		if err := output.WriteSQLite(bars, output.SQLitePath(cfg.OutputFile), columns, meta); err != nil {
+			return fmt.Errorf("write sqlite: %w", err)
+		}
+		return nil
+	}
+	if output.IsDelta(cfg.OutputFile) {
+		if err := output.WriteDelta(bars, output.DeltaPath(cfg.OutputFile), columns); err != nil {
+			return fmt.Errorf("write delta table: %w", err)
+		}
+		return nil
+	}
+
+	if streamStdout {
+		switch format {
+		case output.FormatCSV:
+			return output.WriteCSV(bars, os.Stdout, columns, extras)
+		case output.FormatLineProtocol:
+			return writeLineProtocol(bars, os.Stdout, columns)
+		default:
+			return output.WriteJSONL(bars, os.Stdout, columns, extras)
+		}
+	}
+
+	switch format {
+	case output.FormatCSV, output.FormatJSONL, output.FormatLineProtocol:
+		file, err := os.Create(cfg.OutputFile)
+		if err != nil {
+			return fmt.Errorf("create output: %w", err)
+		}
+		defer file.Close()
+		switch format {
+		case output.FormatCSV:
+			err = output.WriteCSV(bars, file, columns, extras)
+		case output.FormatLineProtocol:
+			err = writeLineProtocol(bars, file, columns)
+		default:
+			err = output.WriteJSONL(bars, file, columns, extras)
+		}
+		if err != nil {
+			return fmt.Errorf("write output: %w", err)
+		}
+		return file.Close()
+	default:
+		if err := output.WriteParquetTransformed(bars, cfg.OutputFile, columns, extras); err != nil {
+			return fmt.Errorf("write parquet: %w", err)
+		}
+		return nil
+	}
+}
+
+// writeLineProtocol streams bars as line protocol with the configured
+// measurement and tags plus the symbol tag
+func writeLineProtocol(bars []aggregator.HourlyResult, w io.Writer, columns []string) error {
+	tags, err := output.ParseTags(cfg.LPTags)
+	if err != nil {
+		return fmt.Errorf("invalid --lp-tags: %w", err)
+	}
+	tags["symbol"] = cfg.Symbol
+	return output.WriteLineProtocol(bars, w, columns, cfg.LPMeasurement, tags)
+}
+
 // resolveDateRange queries Binance for available dates if start/end not specified
+// applyLookback resolves --lookback against the latest available month:
+// the output covers the last N months, and the downloaded range extends
+// further back by the whale window so the first kept month is classified
+// against fully warmed thresholds (the extension is trimmed after
+// processing).
+func applyLookback(latest string) error {
+	spec := strings.TrimSuffix(cfg.Lookback, "m")
+	n, err := strconv.Atoi(spec)
+	if err != nil || n < 1 || spec == cfg.Lookback {
+		return fmt.Errorf("invalid --lookback %q (want a month count like 36m)", cfg.Lookback)
+	}
+
+	anchor := latest
+	if anchor == "" {
+		anchor = time.Now().UTC().Format("2006-01")
+	}
+	t, err := time.Parse("2006-01", anchor)
+	if err != nil {
+		return fmt.Errorf("invalid latest month %q: %w", anchor, err)
+	}
+
+	cfg.EndDate = anchor
+	cfg.LookbackStart = t.AddDate(0, -(n - 1), 0)
+	cfg.StartDate = cfg.LookbackStart.AddDate(0, 0, -config.PercentileWindowDays).Format("2006-01")
+	return nil
+}
+
 func resolveDateRange(cmd *cobra.Command) error {
 	startSet := cmd.Flags().Changed("start")
 	endSet := cmd.Flags().Changed("end")
 
-	// If both are set, nothing to do
-	if startSet && endSet {
+	if cfg.Lookback != "" && (startSet || endSet) {
+		return fmt.Errorf("--lookback already determines the range, drop --start/--end")
+	}
+
+	// Simulated runs never touch the network, including discovery
+	if cfg.Simulate {
+		if cfg.Lookback != "" {
+			return applyLookback("")
+		}
+		if !startSet || !endSet {
+			return fmt.Errorf("--simulate needs an explicit --start and --end")
+		}
+		cfg.StartDate = config.ExpandDatePattern(cfg.StartDate, "", false)
+		cfg.EndDate = config.ExpandDatePattern(cfg.EndDate, "", true)
 		return nil
 	}
 
-	// Need to discover available dates
-	fmt.Printf("Discovering available data for %s...\n", cfg.Symbol)
+	msgW := io.Writer(os.Stdout)
+	if cfg.OutputFile == "-" {
+		msgW = os.Stderr
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Discovery also runs when both dates are set: it caps the range at the
+	// last published month (delisted symbols) and provides expected sizes.
+	// With both dates set a discovery failure is not fatal.
+	fmt.Fprintf(msgW, "Discovering available data for %s...\n", cfg.Symbol)
 	dateRange, err := binance.DiscoverDateRange(ctx, cfg.Symbol)
 	if err != nil {
+		if startSet && endSet {
+			fmt.Fprintf(msgW, "  Discovery failed (%v), using --start/--end as given\n", err)
+			cfg.StartDate = config.ExpandDatePattern(cfg.StartDate, "", false)
+			cfg.EndDate = config.ExpandDatePattern(cfg.EndDate, "", true)
+			return nil
+		}
 		return fmt.Errorf("could not discover available dates for %s: %w\nPlease specify --start and --end manually, or check network connection",
 			cfg.Symbol, err)
 	}
 
+	// Keep expected file sizes for download validation and byte-weighted ETA
+	cfg.MonthSizes = dateRange.MonthSizes
+
+	// Expand fuzzy date forms (bare year, -Nm, latest) against discovery
+	if startSet {
+		cfg.StartDate = config.ExpandDatePattern(cfg.StartDate, dateRange.LastMonth, false)
+	}
+	if endSet {
+		cfg.EndDate = config.ExpandDatePattern(cfg.EndDate, dateRange.LastMonth, true)
+	}
+
+	// Lookback runs anchor on the latest available month
+	if cfg.Lookback != "" {
+		if err := applyLookback(dateRange.LastMonth); err != nil {
+			return err
+		}
+		fmt.Fprintf(msgW, "  Lookback: %s -> %s (plus %d days of whale warmup from %s)\n",
+			cfg.LookbackStart.Format("2006-01"), cfg.EndDate, config.PercentileWindowDays, cfg.StartDate)
+		return nil
+	}
+
 	if !startSet {
 		cfg.StartDate = dateRange.FirstMonth
-		fmt.Printf("  First available: %s\n", cfg.StartDate)
+		fmt.Fprintf(msgW, "  First available: %s\n", cfg.StartDate)
 	}
 
 	if !endSet {
 		cfg.EndDate = dateRange.LastMonth
-		fmt.Printf("  Last available: %s\n", cfg.EndDate)
+		fmt.Fprintf(msgW, "  Last available: %s\n", cfg.EndDate)
+	}
+
+	// An explicit --end past the last published month would 404 on every
+	// nonexistent month: the symbol stopped publishing (delisted or paused).
+	// Stop cleanly at the last available month and record the cutoff.
+	if endSet && monthAfter(cfg.EndDate, dateRange.LastMonth) {
+		fmt.Fprintf(msgW, "  %s stopped publishing after %s (delisted?); ending there instead of %s\n",
+			cfg.Symbol, dateRange.LastMonth, cfg.EndDate)
+		cfg.EndDate = dateRange.LastMonth
+		cfg.DelistedAfter = dateRange.LastMonth
+		cfg.RangeEnd = time.Time{}
 	}
 
 	return nil
 }
+
+// monthAfter reports whether month a (YYYY-MM or YYYY-MM-DD) is after b
+func monthAfter(a, b string) bool {
+	if len(a) > 7 {
+		a = a[:7]
+	}
+	return a > b
+}
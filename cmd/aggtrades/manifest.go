@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/clement/aggtrades/internal/parser"
+	"github.com/clement/aggtrades/internal/pipeline"
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// version is stamped at build time via -ldflags "-X main.version=..."
+var version = "dev"
+
+// runManifest captures everything needed to certify a dataset was produced
+// from specific inputs with specific settings
+type runManifest struct {
+	Tool        string `json:"tool"`
+	Version     string `json:"version"`
+	Commit      string `json:"commit,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	Symbol      string `json:"symbol"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	Fingerprint string `json:"fingerprint"`
+
+	// DelistedAfter is the last published month when the symbol stopped
+	// publishing before the requested end date
+	DelistedAfter string `json:"delisted_after,omitempty"`
+
+	// Sources maps month -> checksum of the ZIP it was computed from.
+	// Months resumed from an extracted CSV have no entry.
+	Sources map[string]state.SourceInfo `json:"sources"`
+
+	OutputFile   string `json:"output_file"`
+	OutputSHA256 string `json:"output_sha256"`
+	OutputBytes  int64  `json:"output_bytes"`
+	Hours        int    `json:"hours"`
+
+	// Network bytes fetched this run, in total and per month (months
+	// resumed from local files download nothing)
+	DownloadedBytes   int64            `json:"downloaded_bytes"`
+	DownloadedByMonth map[string]int64 `json:"downloaded_by_month,omitempty"`
+}
+
+// manifestPath is the manifest file written beside an output file
+func manifestPath(outputFile string) string {
+	return outputFile + ".manifest.json"
+}
+
+// writeRunManifest writes the reproducibility manifest beside the output
+// file after a successful run
+func writeRunManifest(p *pipeline.Pipeline, hours int) error {
+	sum, err := downloader.FileSHA256(cfg.OutputFile)
+	if err != nil {
+		return fmt.Errorf("hash output: %w", err)
+	}
+	_, size := downloader.FileExists(cfg.OutputFile)
+
+	m := runManifest{
+		Tool:          "aggtrades",
+		Version:       version,
+		Commit:        buildCommit(),
+		CreatedAt:     time.Now().UTC().Format(time.RFC3339),
+		Symbol:        cfg.Symbol,
+		StartDate:     cfg.StartDate,
+		EndDate:       cfg.EndDate,
+		Fingerprint:   cfg.Fingerprint(),
+		DelistedAfter: cfg.DelistedAfter,
+		Sources:       p.Sources(),
+		OutputFile:    filepath.Base(cfg.OutputFile),
+		OutputSHA256:  sum,
+		OutputBytes:   size,
+		Hours:         hours,
+	}
+	if m.Sources == nil {
+		m.Sources = map[string]state.SourceInfo{}
+	}
+	m.DownloadedBytes, m.DownloadedByMonth = p.Downloaded()
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath(cfg.OutputFile), append(data, '\n'), 0644)
+}
+
+// newVerifyManifestCmd creates the `verify-manifest` command that re-checks
+// a dataset against its manifest
+func newVerifyManifestCmd() *cobra.Command {
+	var manifestFile string
+	var sample int
+
+	cmd := &cobra.Command{
+		Use:   "verify-manifest",
+		Short: "Verify a dataset against its reproducibility manifest",
+		Long: `Re-checks a dataset against the manifest written beside it: the output
+file checksum, and for a sampled subset of months, the source ZIP checksum
+(re-downloaded) and a recomputation of that month's bars.
+
+Recomputed months are compared on the flow columns only; whale and seasonal
+columns depend on rolling state from prior months and are skipped, as in
+backfill. Exits non-zero if any check fails.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyManifest(cmd, manifestFile, sample)
+		},
+	}
+
+	cmd.Flags().StringVarP(&manifestFile, "manifest", "m", "", "Manifest file (<output>.manifest.json)")
+	cmd.MarkFlagRequired("manifest")
+	cmd.Flags().IntVar(&sample, "sample", 1, "How many months to re-download and recompute (0 = checksums only)")
+
+	return cmd
+}
+
+// verifyManifest performs the checks and reports each one
+func verifyManifest(cmd *cobra.Command, manifestFile string, sample int) error {
+	data, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return fmt.Errorf("read manifest: %w", err)
+	}
+	var m runManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parse manifest: %w", err)
+	}
+
+	outputFile := filepath.Join(filepath.Dir(manifestFile), m.OutputFile)
+	failed := 0
+
+	// 1. Output file checksum
+	sum, err := downloader.FileSHA256(outputFile)
+	switch {
+	case err != nil:
+		fmt.Printf("FAIL output checksum: %v\n", err)
+		failed++
+	case sum != m.OutputSHA256:
+		fmt.Printf("FAIL output checksum: %s changed since the manifest was written\n", m.OutputFile)
+		failed++
+	default:
+		fmt.Printf("ok   output checksum (%s)\n", m.OutputFile)
+	}
+
+	// 2. Sampled source checksums + bar recomputation
+	if sample > 0 && len(m.Sources) > 0 {
+		months := make([]string, 0, len(m.Sources))
+		for month := range m.Sources {
+			months = append(months, month)
+		}
+		sort.Strings(months)
+
+		// Evenly spaced deterministic sample
+		if sample > len(months) {
+			sample = len(months)
+		}
+		step := len(months) / sample
+		cfg.Symbol = m.Symbol
+
+		bars, err := output.ReadParquet(outputFile)
+		if err != nil {
+			return fmt.Errorf("read output: %w", err)
+		}
+
+		for i := 0; i < sample; i++ {
+			month := months[i*step]
+			if err := verifyMonth(cmd, month, m.Sources[month], bars); err != nil {
+				fmt.Printf("FAIL %s: %v\n", month, err)
+				failed++
+			} else {
+				fmt.Printf("ok   %s (source checksum and flow columns match)\n", month)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed; the dataset has drifted from its manifest", failed)
+	}
+	fmt.Println("Dataset matches its manifest")
+	return nil
+}
+
+// verifyMonth re-downloads one month, checks the source checksum, and
+// recomputes the month's bars to compare the flow columns
+func verifyMonth(cmd *cobra.Command, month string, want state.SourceInfo, bars []aggregator.HourlyResult) error {
+	ctx := cmd.Context()
+
+	tempDir, err := os.MkdirTemp("", "aggtrades_verify_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	zipPath := filepath.Join(tempDir, fmt.Sprintf("%s-aggTrades-%s.zip", cfg.Symbol, month))
+	csvPath := filepath.Join(tempDir, fmt.Sprintf("%s-aggTrades-%s.csv", cfg.Symbol, month))
+
+	if _, err := downloader.DownloadWithRetry(ctx, cfg.MonthURL(month), zipPath, nil); err != nil {
+		return fmt.Errorf("download: %w", err)
+	}
+
+	sum, err := downloader.FileSHA256(zipPath)
+	if err != nil {
+		return err
+	}
+	if sum != want.SHA256 {
+		return fmt.Errorf("source checksum changed (upstream file was replaced)")
+	}
+
+	if _, err := downloader.ExtractAndRemoveZip(ctx, zipPath, csvPath, nil); err != nil {
+		return fmt.Errorf("extract: %w", err)
+	}
+
+	agg := aggregator.NewAggregator(config.PercentileWindowDays, config.ReservoirSamplesPerDay)
+	if _, err := parser.ParseCSV(csvPath, func(trades []parser.Trade) error {
+		agg.ProcessTrades(trades)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+	agg.Flush()
+
+	recomputed := make(map[time.Time]*aggregator.HourlyBar)
+	for _, bar := range agg.GetAllBars() {
+		recomputed[bar.Time] = bar.Bar
+	}
+
+	// Compare flow columns for the month's hours present in the output
+	// (warmup-trimmed or gap hours are skipped)
+	compared := 0
+	for _, bar := range bars {
+		if bar.Time.UTC().Format("2006-01") != month || bar.Bar.NTrades == 0 {
+			continue
+		}
+		got, ok := recomputed[bar.Time]
+		if !ok {
+			continue
+		}
+		if got.NTrades != bar.Bar.NTrades ||
+			math.Abs(got.BuyVol-bar.Bar.BuyVol) > 1e-6 ||
+			math.Abs(got.SellVol-bar.Bar.SellVol) > 1e-6 {
+			return fmt.Errorf("recomputed bars differ at %s", bar.Time.UTC().Format("2006-01-02 15:04"))
+		}
+		compared++
+	}
+	if compared == 0 {
+		return fmt.Errorf("no comparable hours in the output for this month")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/binance"
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// runMatrix processes a comma-separated list of symbols, each with its own
+// checkpoint and detector, writing one output file per symbol. Symbols are
+// independent jobs, so with --process-workers > 1 several run concurrently;
+// each symbol stays on a single worker, which keeps within-symbol processing
+// strictly chronological. With --wide it also writes a wide-format matrix
+// aligned on a shared hourly index (columns prefixed per symbol), which
+// modeling pipelines often expect instead of long format.
+func runMatrix(cmd *cobra.Command, msgW io.Writer) error {
+	var columns []string
+	if cfg.Columns != "" {
+		cols, err := output.ParseColumns(cfg.Columns)
+		if err != nil {
+			return fmt.Errorf("invalid --columns: %w", err)
+		}
+		columns = cols
+	}
+
+	var symbols []string
+	for _, s := range strings.Split(cfg.Symbol, ",") {
+		if s = strings.ToUpper(strings.TrimSpace(s)); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+
+	startSet := cmd.Flags().Changed("start")
+	endSet := cmd.Flags().Changed("end")
+
+	// Default matrix output name; per-symbol files derive from it
+	if cfg.OutputFile == "" {
+		cfg.OutputFile = fmt.Sprintf("aggtrades_%s.parquet", strings.Join(symbols, "_"))
+	}
+
+	// Resolve each symbol's range and config up front, sequentially
+	var jobs []*config.Config
+	for _, symbol := range symbols {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		dr, err := binance.DiscoverDateRange(ctx, symbol)
+		cancel()
+		if err != nil && !(startSet && endSet) {
+			return fmt.Errorf("could not discover available dates for %s: %w", symbol, err)
+		}
+
+		pcfg := *cfg
+		pcfg.Symbol = symbol
+		pcfg.TempDir = "" // default per-symbol temp dir
+		pcfg.OutputFile = strings.TrimSuffix(cfg.OutputFile, ".parquet") + "_" + symbol + ".parquet"
+		if dr != nil {
+			pcfg.MonthSizes = dr.MonthSizes
+			if !startSet || dr.FirstMonth > pcfg.StartDate {
+				pcfg.StartDate = dr.FirstMonth
+				pcfg.RangeStart = time.Time{}
+			}
+			if !endSet || dr.LastMonth < pcfg.EndDate {
+				pcfg.EndDate = dr.LastMonth
+				pcfg.RangeEnd = time.Time{}
+			}
+		}
+		if pcfg.StartDate > pcfg.EndDate {
+			fmt.Fprintf(msgW, "  %s: no overlap with requested range, skipping\n", symbol)
+			continue
+		}
+		if err := pcfg.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", symbol, err)
+		}
+		jobs = append(jobs, &pcfg)
+	}
+
+	workers := cfg.ProcessWorkers
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	// Split the memory budget between concurrent symbols
+	if workers > 1 {
+		for _, pcfg := range jobs {
+			pcfg.MaxMemoryBytes /= int64(workers)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	series := make(map[string][]aggregator.HourlyResult)
+	sem := make(chan struct{}, workers)
+	for _, pcfg := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(pcfg *config.Config) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fmt.Fprintf(msgW, "\n=== %s (%s to %s) ===\n", pcfg.Symbol, pcfg.StartDate, pcfg.EndDate)
+			bars, err := runVenue(pcfg)
+			if err == nil && len(bars) > 0 {
+				if err = output.WriteParquet(bars, pcfg.OutputFile); err != nil {
+					err = fmt.Errorf("write: %w", err)
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				if firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", pcfg.Symbol, err)
+				}
+			case len(bars) == 0:
+				fmt.Fprintf(msgW, "  %s: no data\n", pcfg.Symbol)
+			default:
+				fmt.Fprintf(msgW, "  %s: %d hours → %s\n", pcfg.Symbol, len(bars), pcfg.OutputFile)
+				series[pcfg.Symbol] = bars
+			}
+		}(pcfg)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if len(series) == 0 {
+		return fmt.Errorf("no symbol produced data")
+	}
+
+	if cfg.Wide {
+		cols := columns
+		if cols == nil {
+			cols = output.ColumnNames()
+		}
+		if err := output.WriteWide(series, cfg.OutputFile, cols); err != nil {
+			return fmt.Errorf("write wide matrix: %w", err)
+		}
+		fmt.Fprintf(msgW, "\nWide matrix: %d symbols → %s\n", len(series), cfg.OutputFile)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+	"github.com/wcharczuk/go-chart/v2"
+)
+
+// newPlotCmd creates the `plot` command for rendering time-series charts
+// from an existing output file
+func newPlotCmd() *cobra.Command {
+	var inputFile string
+	var outFile string
+	var columns string
+
+	cmd := &cobra.Command{
+		Use:   "plot",
+		Short: "Render a time-series chart from an existing output file",
+		Long: `Renders one or more output columns from a parquet output as a simple
+time-series PNG, so a run's results can be eyeballed without spinning up a
+notebook:
+
+  aggtrades plot -i bars.parquet --column whale_buy_vol_p99 --out chart.png
+
+Multiple columns (comma-separated) are drawn as separate series on a shared
+axis; NaN values are left as gaps.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names, err := output.ParseColumns(columns)
+			if err != nil {
+				return fmt.Errorf("invalid --column: %w", err)
+			}
+			if len(names) == 0 {
+				return fmt.Errorf("--column is required")
+			}
+
+			bars, err := output.ReadParquet(inputFile)
+			if err != nil {
+				return fmt.Errorf("read input: %w", err)
+			}
+			if len(bars) == 0 {
+				return fmt.Errorf("no rows in %s", inputFile)
+			}
+
+			if err := renderChart(bars, names, outFile); err != nil {
+				return err
+			}
+			fmt.Printf("Chart: %d hours, %s → %s\n", len(bars), strings.Join(names, ", "), outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Output file to plot (parquet)")
+	cmd.Flags().StringVar(&columns, "column", "", "Column(s) to plot, comma-separated")
+	cmd.Flags().StringVar(&outFile, "out", "chart.png", "Destination PNG file")
+	cmd.MarkFlagRequired("input")
+	cmd.MarkFlagRequired("column")
+
+	return cmd
+}
+
+// renderChart draws the selected columns as time series into a PNG
+func renderChart(bars []aggregator.HourlyResult, names []string, outFile string) error {
+	var series []chart.Series
+	for _, name := range names {
+		col, _ := aggregator.ColumnByName(name)
+
+		xs := make([]float64, 0, len(bars))
+		ys := make([]float64, 0, len(bars))
+		for _, bar := range bars {
+			var y float64
+			switch v := col.Value(bar.Bar).(type) {
+			case int64:
+				y = float64(v)
+			case float64:
+				y = v
+			}
+			if math.IsNaN(y) {
+				continue
+			}
+			xs = append(xs, float64(bar.Time.UTC().Unix()))
+			ys = append(ys, y)
+		}
+		if len(xs) == 0 {
+			return fmt.Errorf("column %s has no plottable values", name)
+		}
+
+		series = append(series, chart.ContinuousSeries{
+			Name:    name,
+			XValues: xs,
+			YValues: ys,
+		})
+	}
+
+	graph := chart.Chart{
+		Width:  1280,
+		Height: 480,
+		XAxis: chart.XAxis{
+			Name: "time",
+			ValueFormatter: func(v any) string {
+				f, ok := v.(float64)
+				if !ok {
+					return ""
+				}
+				return chart.TimeFromFloat64(f * 1e9).UTC().Format("2006-01-02")
+			},
+		},
+		Series: series,
+	}
+	if len(series) > 1 {
+		graph.Elements = []chart.Renderable{chart.Legend(&graph)}
+	}
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("create chart file: %w", err)
+	}
+	defer f.Close()
+
+	if err := graph.Render(chart.PNG, f); err != nil {
+		return fmt.Errorf("render chart: %w", err)
+	}
+	return f.Close()
+}
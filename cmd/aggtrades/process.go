@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// monthFilePattern matches the per-month ZIP/CSV artifacts in the temp dir
+var monthFilePattern = regexp.MustCompile(`^(\d{4}-\d{2})\.(zip|csv)$`)
+
+// newProcessCmd creates the `process` command: aggregate from ZIPs/CSVs
+// already in the temp directory without touching the network, the
+// counterpart to `download`
+func newProcessCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "process",
+		Short: "Aggregate from local files only, without downloading",
+		Long: `Runs the normal aggregation pipeline over the monthly ZIPs and CSVs
+already in the temp directory (for example fetched earlier with the download
+command) and writes the output file. Months missing locally fail instead of
+being downloaded. Without --start/--end the range is taken from the files
+present.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tempDir := cfg.TempDir
+			if tempDir == "" {
+				tempDir = fmt.Sprintf("./aggtrades_temp_%s", cfg.Symbol)
+			}
+
+			// Default the range to the months present on disk
+			if !cmd.Flags().Changed("start") || !cmd.Flags().Changed("end") {
+				months, err := localMonths(tempDir)
+				if err != nil {
+					return err
+				}
+				if len(months) == 0 {
+					return fmt.Errorf("no monthly ZIP/CSV files in %s (run download first, or pass --start/--end)", tempDir)
+				}
+				if !cmd.Flags().Changed("start") {
+					cfg.StartDate = months[0]
+				}
+				if !cmd.Flags().Changed("end") {
+					cfg.EndDate = months[len(months)-1]
+				}
+			}
+
+			cfg.Offline = true
+			if err := cfg.Validate(); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+
+			format := output.DetectFormat(cfg.OutputFile)
+			if cfg.Format != "" {
+				var err error
+				format, err = output.ParseFormat(cfg.Format)
+				if err != nil {
+					return err
+				}
+			}
+
+			fmt.Printf("Processing %s from local files: %s to %s\n", cfg.Symbol, cfg.StartDate, cfg.EndDate)
+			bars, err := runVenue(cfg)
+			if err != nil {
+				return err
+			}
+			if len(bars) == 0 {
+				return fmt.Errorf("no data produced")
+			}
+
+			if err := writeBars(bars, format, output.ColumnNames(), nil, false); err != nil {
+				return err
+			}
+			fmt.Printf("%d hours → %s\n", len(bars), cfg.OutputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.StartDate, "start", "", "Start date (YYYY-MM, defaults to first local month)")
+	cmd.Flags().StringVar(&cfg.EndDate, "end", "", "End date (YYYY-MM, defaults to last local month)")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	cmd.Flags().StringVarP(&cfg.OutputFile, "output", "o", "", "Output file")
+	cmd.Flags().StringVar(&cfg.Format, "format", "", "Output format: parquet, csv, or jsonl (default from extension)")
+	return cmd
+}
+
+// localMonths lists the months with a ZIP or CSV in the temp dir, sorted
+func localMonths(tempDir string) ([]string, error) {
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan temp dir: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if m := monthFilePattern.FindStringSubmatch(entry.Name()); m != nil {
+			seen[m[1]] = true
+		}
+	}
+
+	months := make([]string, 0, len(seen))
+	for month := range seen {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	return months, nil
+}
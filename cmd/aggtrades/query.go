@@ -0,0 +1,177 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+
+	_ "modernc.org/sqlite"
+)
+
+// newQueryCmd creates the `query` command for running SQL against an
+// existing output file
+func newQueryCmd() *cobra.Command {
+	var inputFile string
+
+	cmd := &cobra.Command{
+		Use:   "query [SQL]",
+		Short: "Run SQL against an existing output file",
+		Long: `Loads an output file (parquet, or a sqlite:// database produced by this
+tool) into an embedded SQLite engine and runs the given SQL against it, for
+quick sanity checks without leaving the tool.
+
+Parquet inputs are loaded into an in-memory table named bars with one column
+per output column plus time (ISO 8601 text, so SQLite's date functions work):
+
+  aggtrades query -i bars.parquet "select strftime('%Y-%m-%d', time) day,
+    sum(buy_vol) from bars group by day order by day"
+
+The SQL dialect is SQLite's.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := openQueryDB(inputFile)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			return runQuery(db, args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Output file to query (parquet or sqlite://path)")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
+
+// openQueryDB opens a sqlite:// input directly, or loads a parquet input
+// into an in-memory bars table
+func openQueryDB(input string) (*sql.DB, error) {
+	if output.IsSQLite(input) {
+		db, err := sql.Open("sqlite", output.SQLitePath(input))
+		if err != nil {
+			return nil, fmt.Errorf("open sqlite db: %w", err)
+		}
+		return db, nil
+	}
+
+	bars, err := output.ReadParquet(input)
+	if err != nil {
+		return nil, fmt.Errorf("read input: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("open in-memory db: %w", err)
+	}
+
+	// One column per registry column, plus ISO 8601 time
+	var cols, marks strings.Builder
+	for _, col := range aggregator.Columns {
+		cols.WriteString(", ")
+		cols.WriteString(col.Name)
+		if col.Type == aggregator.ColumnInt64 {
+			cols.WriteString(" INTEGER")
+		} else {
+			cols.WriteString(" REAL")
+		}
+		marks.WriteString(", ?")
+	}
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE bars (time TEXT NOT NULL%s)", cols.String())); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create table: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO bars VALUES (?%s)", marks.String()))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	defer stmt.Close()
+
+	args := make([]any, 0, len(aggregator.Columns)+1)
+	for _, bar := range bars {
+		args = args[:0]
+		args = append(args, bar.Time.UTC().Format(time.RFC3339))
+		for _, col := range aggregator.Columns {
+			args = append(args, col.Value(bar.Bar))
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("load bars: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// runQuery executes the SQL and prints the result as an aligned table
+func runQuery(db *sql.DB, query string) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(names, "\t"))
+
+	values := make([]any, len(names))
+	ptrs := make([]any, len(names))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	count := 0
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			switch x := v.(type) {
+			case nil:
+				fields[i] = "NULL"
+			case []byte:
+				fields[i] = string(x)
+			case float64:
+				fields[i] = fmt.Sprintf("%g", x)
+			default:
+				fields[i] = fmt.Sprintf("%v", x)
+			}
+		}
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("(%d rows)\n", count)
+	return nil
+}
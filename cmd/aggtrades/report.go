@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/clement/aggtrades/internal/whale"
+	"github.com/spf13/cobra"
+)
+
+// reportTopEvents is how many whale hours the report lists
+const reportTopEvents = 15
+
+// newReportCmd creates the `report` command producing a self-contained HTML
+// summary of a run
+func newReportCmd() *cobra.Command {
+	var inputFile string
+	var outFile string
+
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Generate a self-contained HTML report of a run",
+		Long: `Builds a single-file HTML report from an output file and, when present,
+the checkpoint: coverage and gaps, monthly volumes, whale threshold
+evolution over the detector's rolling window, and the top whale events.
+
+The checkpoint directory is derived from --symbol; pass the same symbol the
+run used to include threshold evolution.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bars, err := output.ReadParquet(inputFile)
+			if err != nil {
+				return fmt.Errorf("read input: %w", err)
+			}
+			if len(bars) == 0 {
+				return fmt.Errorf("no rows in %s", inputFile)
+			}
+
+			data := buildReport(bars)
+			if err := writeReport(data, outFile); err != nil {
+				return err
+			}
+			fmt.Printf("Report: %d hours → %s\n", len(bars), outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Output file to report on (parquet)")
+	cmd.Flags().StringVar(&outFile, "out", "report.html", "Destination HTML file")
+	cmd.MarkFlagRequired("input")
+
+	return cmd
+}
+
+// reportData is everything the HTML template renders
+type reportData struct {
+	Symbol    string
+	Generated string
+	Input     string
+
+	// Coverage
+	FirstHour string
+	LastHour  string
+	Hours     int
+	GapHours  int
+	NaNHours  int
+	Trades    int64
+	Volume    float64
+
+	Months     []reportMonth
+	Thresholds []reportThreshold
+	TopEvents  []reportEvent
+}
+
+type reportMonth struct {
+	Month  string
+	Hours  int
+	Gaps   int
+	Trades int64
+	Volume float64
+	Pct    float64 // of the largest month's volume, for the bar widths
+}
+
+type reportThreshold struct {
+	Date string
+	P99  float64
+	P999 float64
+}
+
+type reportEvent struct {
+	Time    string
+	Side    string
+	Volume  float64
+	Count   int64
+	NTrades int64
+}
+
+// buildReport computes the report sections from the bars and, when the
+// checkpoint exists, the detector state
+func buildReport(bars []aggregator.HourlyResult) reportData {
+	data := reportData{
+		Symbol:    cfg.Symbol,
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		FirstHour: bars[0].Time.UTC().Format("2006-01-02 15:04"),
+		LastHour:  bars[len(bars)-1].Time.UTC().Format("2006-01-02 15:04"),
+		Hours:     len(bars),
+	}
+
+	// Coverage and per-month aggregates
+	byMonth := make(map[string]*reportMonth)
+	var monthOrder []string
+	for _, bar := range bars {
+		month := bar.Time.UTC().Format("2006-01")
+		m, ok := byMonth[month]
+		if !ok {
+			m = &reportMonth{Month: month}
+			byMonth[month] = m
+			monthOrder = append(monthOrder, month)
+		}
+		m.Hours++
+		vol := bar.Bar.TotalVolume()
+		if bar.Bar.NTrades == 0 {
+			m.Gaps++
+			data.GapHours++
+		}
+		m.Trades += bar.Bar.NTrades
+		m.Volume += vol
+		data.Trades += bar.Bar.NTrades
+		data.Volume += vol
+	}
+	sort.Strings(monthOrder)
+
+	var maxVolume float64
+	for _, m := range byMonth {
+		if m.Volume > maxVolume {
+			maxVolume = m.Volume
+		}
+	}
+	for _, month := range monthOrder {
+		m := byMonth[month]
+		if maxVolume > 0 {
+			m.Pct = m.Volume / maxVolume * 100
+		}
+		data.Months = append(data.Months, *m)
+	}
+
+	// Threshold evolution from the checkpoint's detector state (covers the
+	// rolling window only; silently skipped when no checkpoint exists)
+	if ds, err := state.LoadDetector(state.NewManager(cfg.CheckpointDir()).DetectorPath()); err == nil && ds != nil {
+		detector := whale.NewDetector(ds.WindowDays, ds.SamplesPerDay)
+		detector.ImportState(ds.ToWhaleReservoirs(), ds.DateOrder)
+		for _, date := range ds.DateOrder {
+			t := detector.GetThresholds(date)
+			data.Thresholds = append(data.Thresholds, reportThreshold{Date: date, P99: t.P99, P999: t.P999})
+		}
+	}
+
+	// Top whale events by P99.9 volume, either side
+	type scored struct {
+		bar   aggregator.HourlyResult
+		side  string
+		vol   float64
+		count int64
+	}
+	events := make([]scored, 0, len(bars))
+	for _, bar := range bars {
+		if bar.Bar.WhaleBuyVolP999 >= bar.Bar.WhaleSellVolP999 {
+			events = append(events, scored{bar, "buy", bar.Bar.WhaleBuyVolP999, bar.Bar.WhaleBuyCountP999})
+		} else {
+			events = append(events, scored{bar, "sell", bar.Bar.WhaleSellVolP999, bar.Bar.WhaleSellCountP999})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].vol > events[j].vol })
+	for i := 0; i < len(events) && i < reportTopEvents; i++ {
+		e := events[i]
+		if e.vol == 0 {
+			break
+		}
+		data.TopEvents = append(data.TopEvents, reportEvent{
+			Time:    e.bar.Time.UTC().Format("2006-01-02 15:04"),
+			Side:    e.side,
+			Volume:  e.vol,
+			Count:   e.count,
+			NTrades: e.bar.Bar.NTrades,
+		})
+	}
+
+	return data
+}
+
+// writeReport renders the HTML template to the destination file
+func writeReport(data reportData, outFile string) error {
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("create report: %w", err)
+	}
+	defer f.Close()
+
+	if err := reportTemplate.Execute(f, data); err != nil {
+		return fmt.Errorf("render report: %w", err)
+	}
+	return f.Close()
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>aggtrades report — {{.Symbol}}</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+h1 { font-size: 1.4em; } h2 { font-size: 1.1em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+th, td { text-align: right; padding: 0.3em 0.6em; border-bottom: 1px solid #ddd; }
+th:first-child, td:first-child { text-align: left; }
+.bar { background: #4a90d9; height: 0.8em; display: inline-block; }
+.muted { color: #888; font-size: 0.85em; }
+.buy { color: #1a7f37; } .sell { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>aggtrades report — {{.Symbol}}</h1>
+<p class="muted">Generated {{.Generated}}</p>
+
+<h2>Coverage</h2>
+<table>
+<tr><td>Range</td><td>{{.FirstHour}} → {{.LastHour}} UTC</td></tr>
+<tr><td>Hours</td><td>{{.Hours}}</td></tr>
+<tr><td>Gap hours (zero-filled)</td><td>{{.GapHours}}</td></tr>
+<tr><td>Trades</td><td>{{.Trades}}</td></tr>
+<tr><td>Volume</td><td>{{printf "%.2f" .Volume}}</td></tr>
+</table>
+
+<h2>Monthly volumes</h2>
+<table>
+<tr><th>Month</th><th>Hours</th><th>Gaps</th><th>Trades</th><th>Volume</th><th style="text-align:left"></th></tr>
+{{range .Months}}
+<tr><td>{{.Month}}</td><td>{{.Hours}}</td><td>{{.Gaps}}</td><td>{{.Trades}}</td><td>{{printf "%.2f" .Volume}}</td>
+<td style="text-align:left"><span class="bar" style="width:{{printf "%.0f" .Pct}}px"></span></td></tr>
+{{end}}
+</table>
+
+{{if .Thresholds}}
+<h2>Whale threshold evolution</h2>
+<p class="muted">From the checkpoint's detector state (rolling window only).</p>
+<table>
+<tr><th>Date</th><th>P99</th><th>P99.9</th></tr>
+{{range .Thresholds}}
+<tr><td>{{.Date}}</td><td>{{printf "%.4f" .P99}}</td><td>{{printf "%.4f" .P999}}</td></tr>
+{{end}}
+</table>
+{{end}}
+
+{{if .TopEvents}}
+<h2>Top whale events (P99.9 volume)</h2>
+<table>
+<tr><th>Hour (UTC)</th><th>Side</th><th>Whale volume</th><th>Whale trades</th><th>All trades</th></tr>
+{{range .TopEvents}}
+<tr><td>{{.Time}}</td><td class="{{.Side}}">{{.Side}}</td><td>{{printf "%.2f" .Volume}}</td><td>{{.Count}}</td><td>{{.NTrades}}</td></tr>
+{{end}}
+</table>
+{{end}}
+
+</body>
+</html>
+`))
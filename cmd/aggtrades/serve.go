@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/clement/aggtrades/internal/pipeline"
+)
+
+// startControlServer exposes a small REST API for runtime control of a
+// running pipeline: status, pause/resume/cancel, and live worker counts.
+func startControlServer(addr string, p *pipeline.Pipeline) (*http.Server, error) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.Status())
+	})
+
+	mux.HandleFunc("/api/pause", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		p.Pause()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/api/resume", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		p.Resume()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/api/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		p.Stop()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/api/workers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST required", http.StatusMethodNotAllowed)
+			return
+		}
+		n, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil || n < 1 {
+			http.Error(w, "query parameter n must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		p.SetDownloadWorkers(n)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p.Status())
+	})
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go srv.Serve(ln)
+	return srv, nil
+}
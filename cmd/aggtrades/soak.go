@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// soakGoroutineSlack is how many goroutines above the baseline count as
+// normal churn (GC workers, finalizers) rather than a leak
+const soakGoroutineSlack = 10
+
+// newSoakCmd creates the hidden `soak` command: an internal stress
+// harness that loops synthetic months through the full pipeline for
+// hours, asserting memory stays bounded, checkpoints stay consistent,
+// and the UI does not leak goroutines — confidence before month-long
+// universe builds.
+func newSoakCmd() *cobra.Command {
+	var duration time.Duration
+	var months int
+	var maxHeapMB int
+
+	cmd := &cobra.Command{
+		Use:    "soak",
+		Short:  "Stress the pipeline with synthetic months for hours",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if months < 1 {
+				return fmt.Errorf("months must be at least 1, got %d", months)
+			}
+
+			tempDir := filepath.Join(os.TempDir(), fmt.Sprintf("aggtrades_soak_%d", os.Getpid()))
+			defer os.RemoveAll(tempDir)
+
+			baseline := runtime.NumGoroutine()
+			deadline := time.Now().Add(duration)
+			var peakHeap uint64
+			iter := 0
+
+			fmt.Printf("Soaking for %s: %d synthetic months per iteration, %d goroutines at baseline\n",
+				duration, months, baseline)
+
+			for time.Now().Before(deadline) {
+				iter++
+
+				scfg := config.New()
+				scfg.Symbol = cfg.Symbol
+				scfg.Simulate = true
+				scfg.NoTUI = true
+				scfg.StartDate = "2024-01"
+				scfg.EndDate = time.Date(2024, time.Month(months), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+				scfg.TempDir = tempDir
+				scfg.OutputFile = filepath.Join(tempDir, "soak.parquet")
+				if err := scfg.Validate(); err != nil {
+					return fmt.Errorf("iteration %d: %w", iter, err)
+				}
+
+				bars, err := runVenueRaw(scfg)
+				if err != nil {
+					return fmt.Errorf("iteration %d: %w", iter, err)
+				}
+
+				// Checkpoint consistency: the saved state must cover every
+				// month and reload into exactly the bars the run returned
+				mgr := state.NewManager(scfg.CheckpointDir())
+				st, err := mgr.Load()
+				if err != nil {
+					return fmt.Errorf("iteration %d: load state: %w", iter, err)
+				}
+				if st == nil || len(st.CompletedMonths) != months {
+					got := 0
+					if st != nil {
+						got = len(st.CompletedMonths)
+					}
+					return fmt.Errorf("iteration %d: checkpoint covers %d months, expected %d", iter, got, months)
+				}
+				loaded, err := state.LoadAllBars(mgr.BarsDir(), st.CompletedMonths)
+				if err != nil {
+					return fmt.Errorf("iteration %d: reload bars: %w", iter, err)
+				}
+				if len(loaded) != len(bars) {
+					return fmt.Errorf("iteration %d: checkpoint reloads %d bars, run produced %d", iter, len(loaded), len(bars))
+				}
+
+				// Fresh state every iteration, like a new universe build
+				if err := os.RemoveAll(tempDir); err != nil {
+					return fmt.Errorf("iteration %d: clean temp dir: %w", iter, err)
+				}
+
+				// Memory must return to a bounded level once the run's
+				// garbage is collectable; monotonic growth here is a leak
+				runtime.GC()
+				var ms runtime.MemStats
+				runtime.ReadMemStats(&ms)
+				if ms.HeapAlloc > peakHeap {
+					peakHeap = ms.HeapAlloc
+				}
+				if maxHeapMB > 0 && ms.HeapAlloc > uint64(maxHeapMB)*1024*1024 {
+					return fmt.Errorf("iteration %d: heap %.1f MB exceeds --max-heap %d MB", iter,
+						float64(ms.HeapAlloc)/1024/1024, maxHeapMB)
+				}
+
+				goroutines := runtime.NumGoroutine()
+				if goroutines > baseline+soakGoroutineSlack {
+					return fmt.Errorf("iteration %d: goroutine leak: %d running vs %d at baseline", iter, goroutines, baseline)
+				}
+
+				fmt.Printf("iter %d: %d bars, heap %.1f MB (peak %.1f), %d goroutines\n",
+					iter, len(bars), float64(ms.HeapAlloc)/1024/1024, float64(peakHeap)/1024/1024, goroutines)
+			}
+
+			fmt.Printf("Soak passed: %d iterations in %s, peak settled heap %.1f MB\n",
+				iter, duration, float64(peakHeap)/1024/1024)
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&duration, "duration", time.Hour, "How long to keep looping")
+	cmd.Flags().IntVar(&months, "months", 3, "Synthetic months per iteration")
+	cmd.Flags().IntVar(&maxHeapMB, "max-heap", 0, "Fail when the settled heap exceeds this many MB (0 disables)")
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Symbol name used for the synthetic runs")
+	return cmd
+}
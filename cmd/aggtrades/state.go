@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// newStateCmd creates the `state` command group for checkpoint management
+func newStateCmd() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state",
+		Short: "Manage checkpoint state",
+	}
+
+	stateCmd.PersistentFlags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	stateCmd.PersistentFlags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+
+	stateCmd.AddCommand(newStateExportCmd())
+	stateCmd.AddCommand(newStateImportCmd())
+	stateCmd.AddCommand(newStateShowCmd())
+
+	return stateCmd
+}
+
+// newStateShowCmd creates the `state show` command
+func newStateShowCmd() *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show checkpoint state (read-only)",
+		Long: `Prints completed months, detector window dates, bootstrap status,
+checkpoint sizes, and the last update time without modifying anything.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := state.NewManager(cfg.CheckpointDir())
+
+			info, err := state.Inspect(mgr)
+			if err != nil {
+				return fmt.Errorf("inspect state: %w", err)
+			}
+			if info == nil {
+				fmt.Printf("No checkpoint found in %s\n", mgr.Dir())
+				return nil
+			}
+
+			if asJSON {
+				data, err := json.MarshalIndent(info, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			fmt.Printf("Checkpoint: %s\n", mgr.Dir())
+			fmt.Printf("Last update: %s\n", info.LastUpdate.Format(time.RFC3339))
+			fmt.Printf("Completed months: %d\n", len(info.CompletedMonths))
+			for _, m := range info.CompletedMonths {
+				fmt.Printf("  %s\n", m)
+			}
+			if len(info.DetectorDates) > 0 {
+				fmt.Printf("Detector window: %d days (%s → %s)\n",
+					len(info.DetectorDates),
+					info.DetectorDates[0],
+					info.DetectorDates[len(info.DetectorDates)-1])
+			} else {
+				fmt.Println("Detector window: empty")
+			}
+			fmt.Printf("Bootstrapped: %v\n", info.Bootstrapped)
+			fmt.Printf("Checkpoint size: %.1f MB (%d bar files)\n",
+				float64(info.TotalSizeBytes)/1024/1024, info.BarFileCount)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output as JSON")
+	return cmd
+}
+
+// newStateExportCmd creates the `state export` command
+func newStateExportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export <archive.tar.zst>",
+		Short: "Export checkpoint state to a portable archive",
+		Long: `Bundles state.json, detector.json, and per-month bars into a single
+zstd-compressed tar archive so a long run can be moved to another machine.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := state.NewManager(cfg.CheckpointDir())
+			if err := mgr.Export(args[0]); err != nil {
+				return fmt.Errorf("export state: %w", err)
+			}
+			fmt.Printf("Exported checkpoint from %s to %s\n", mgr.Dir(), args[0])
+			return nil
+		},
+	}
+}
+
+// newStateImportCmd creates the `state import` command
+func newStateImportCmd() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "import <archive.tar.zst>",
+		Short: "Import checkpoint state from a portable archive",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := state.NewManager(cfg.CheckpointDir())
+			if err := mgr.Import(args[0], overwrite); err != nil {
+				return fmt.Errorf("import state: %w", err)
+			}
+			fmt.Printf("Imported checkpoint from %s to %s\n", args[0], mgr.Dir())
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite existing checkpoint")
+	return cmd
+}
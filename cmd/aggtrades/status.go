@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clement/aggtrades/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// newStatusCmd creates the `status` command, a one-screen progress view of
+// the checkpoint. `state show` has the full detail; this answers "how far
+// along is the run" at a glance.
+func newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show checkpoint progress",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			mgr := state.NewManager(cfg.CheckpointDir())
+			info, err := state.Inspect(mgr)
+			if err != nil {
+				return fmt.Errorf("inspect state: %w", err)
+			}
+			if info == nil {
+				fmt.Printf("No checkpoint found in %s\n", mgr.Dir())
+				return nil
+			}
+
+			fmt.Printf("Checkpoint: %s\n", mgr.Dir())
+			months := info.CompletedMonths
+			if len(months) == 0 {
+				fmt.Println("Completed: no months yet")
+			} else {
+				fmt.Printf("Completed: %d months (%s → %s)\n", len(months), months[0], months[len(months)-1])
+			}
+			fmt.Printf("Last update: %s (%s ago)\n",
+				info.LastUpdate.Format(time.RFC3339),
+				time.Since(info.LastUpdate).Round(time.Minute))
+			fmt.Printf("Size: %.1f MB (%d bar files)\n",
+				float64(info.TotalSizeBytes)/1024/1024, info.BarFileCount)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	return cmd
+}
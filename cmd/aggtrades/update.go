@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/binance"
+	"github.com/clement/aggtrades/internal/config"
+	"github.com/clement/aggtrades/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// newUpdateCmd creates the `update` command: extend an existing parquet
+// output with the months published since its last bar, instead of
+// regenerating the whole history every month
+func newUpdateCmd() *cobra.Command {
+	var inputFile string
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Append newly published months to an existing output file",
+		Long: `Reads an existing parquet output, finds its last bar, downloads only the
+months published since, and appends the new hourly bars, replacing the file
+atomically.
+
+The whale detector resumes from the checkpoint of the run that produced the
+file, so classification stays continuous across updates. If the checkpoint
+(in the temp directory) was cleaned, the detector re-bootstraps on the new
+months and whale columns near the seam are less comparable.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			bars, err := output.ReadParquet(inputFile)
+			if err != nil {
+				return fmt.Errorf("read input: %w", err)
+			}
+			if len(bars) == 0 {
+				return fmt.Errorf("no rows in %s", inputFile)
+			}
+			sort.Slice(bars, func(i, j int) bool {
+				return bars[i].Time.Before(bars[j].Time)
+			})
+			last := bars[len(bars)-1].Time
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+			dateRange, err := binance.DiscoverDateRange(ctx, cfg.Symbol)
+			cancel()
+			if err != nil {
+				return fmt.Errorf("could not discover available dates for %s: %w", cfg.Symbol, err)
+			}
+
+			// Resume from the month containing the first missing hour; a
+			// partially covered month is re-downloaded in full and its
+			// already-present hours are kept from the file
+			cfg.StartDate = last.Add(time.Hour).Format("2006-01")
+			cfg.EndDate = dateRange.LastMonth
+			cfg.MonthSizes = dateRange.MonthSizes
+			if cfg.StartDate > cfg.EndDate {
+				fmt.Printf("%s is up to date (last bar %s, last published month %s)\n",
+					inputFile, last.Format("2006-01-02 15:04"), dateRange.LastMonth)
+				return nil
+			}
+			cfg.OutputFile = inputFile
+			if err := cfg.Validate(); err != nil {
+				return fmt.Errorf("configuration error: %w", err)
+			}
+
+			fmt.Printf("Updating %s: last bar %s, fetching %s to %s\n",
+				inputFile, last.Format("2006-01-02 15:04"), cfg.StartDate, cfg.EndDate)
+
+			// Raw run: no warmup trim (the detector is already warm from
+			// the checkpoint) and no gap filling yet (the seam is filled
+			// after merging)
+			newBars, err := runVenueRaw(cfg)
+			if err != nil {
+				return err
+			}
+			newBars = output.TrimBefore(newBars, last.Add(time.Hour))
+			if len(newBars) == 0 {
+				fmt.Println("No new hours produced; file unchanged")
+				return nil
+			}
+
+			countFill, err := output.ParseGapFill(cfg.CountFill)
+			if err != nil {
+				return fmt.Errorf("invalid --count-fill: %w", err)
+			}
+			merged := append(bars, newBars...)
+			merged, _, _ = output.FillGapsMode(merged, config.MaxGapToInterpolate, countFill)
+
+			// WriteParquet replaces the file atomically, so a crash here
+			// cannot destroy the original
+			if err := output.WriteParquet(merged, inputFile); err != nil {
+				return fmt.Errorf("write output: %w", err)
+			}
+
+			fmt.Printf("Appended %d hours (%s → %s), %d total in %s\n",
+				len(newBars),
+				newBars[0].Time.Format("2006-01-02 15:04"),
+				newBars[len(newBars)-1].Time.Format("2006-01-02 15:04"),
+				len(merged), inputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&inputFile, "input", "i", "", "Existing parquet output to extend (required)")
+	cmd.MarkFlagRequired("input")
+	cmd.Flags().StringVarP(&cfg.Symbol, "symbol", "s", cfg.Symbol, "Trading pair symbol")
+	cmd.Flags().StringVar(&cfg.TempDir, "temp-dir", "", "Temp directory (defaults to ./aggtrades_temp_<symbol>)")
+	return cmd
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// commit is stamped at build time via -ldflags "-X main.commit=..."; when
+// absent it falls back to the VCS revision Go embeds in module builds
+var commit = ""
+
+// releaseEndpoint serves the latest release tag for the self-update check
+const releaseEndpoint = "https://api.github.com/repos/clement/aggtrades/releases/latest"
+
+// buildCommit returns the stamped commit, or the module VCS revision when
+// building without ldflags (go install / go build from a clean tree)
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// newVersionCmd creates the `version` command. Dataset reproducibility
+// depends on knowing the exact binary that produced a file, so the same
+// version/commit pair printed here is what the manifest records.
+func newVersionCmd() *cobra.Command {
+	var check bool
+
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print version and build information",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fmt.Printf("aggtrades %s (commit %s, %s, %s/%s)\n",
+				version, buildCommit(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+			if !check {
+				return nil
+			}
+			latest, err := latestRelease()
+			if err != nil {
+				return fmt.Errorf("release check: %w", err)
+			}
+			switch {
+			case latest == version:
+				fmt.Println("Up to date")
+			case version == "dev":
+				fmt.Printf("Latest release: %s (this is a dev build)\n", latest)
+			default:
+				fmt.Printf("Newer release available: %s\n", latest)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&check, "check", false, "Check the release endpoint for a newer version")
+	return cmd
+}
+
+// latestRelease fetches the latest release tag from the release endpoint
+func latestRelease() (string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(releaseEndpoint)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("no tag_name in response")
+	}
+	return release.TagName, nil
+}
@@ -0,0 +1,162 @@
+package aggregator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clement/aggtrades/internal/parser"
+)
+
+// BarMode selects which accumulation closes an event bar
+type BarMode int
+
+const (
+	// TickBars close after a fixed number of trades
+	TickBars BarMode = iota
+	// VolumeBars close after a fixed base-asset volume
+	VolumeBars
+	// DollarBars close after a fixed quote-asset (dollar) volume
+	DollarBars
+)
+
+// ParseBarMode parses a bar mode name as given on the command line
+func ParseBarMode(s string) (BarMode, error) {
+	switch s {
+	case "tick":
+		return TickBars, nil
+	case "volume":
+		return VolumeBars, nil
+	case "dollar":
+		return DollarBars, nil
+	default:
+		return 0, fmt.Errorf("unknown bar mode %q (expected tick, volume, or dollar)", s)
+	}
+}
+
+// EventBar is one information-driven bar. Bars are irregular in time, so
+// each carries its own open/close timestamps instead of a shared hourly
+// index.
+type EventBar struct {
+	OpenTime  time.Time
+	CloseTime time.Time
+
+	OpenPrice  float64
+	HighPrice  float64
+	LowPrice   float64
+	ClosePrice float64
+	VWAP       float64
+
+	Volume       float64
+	DollarVolume float64
+	BuyVolume    float64
+	SellVolume   float64
+	NTrades      int64
+
+	FirstAggID int64
+	LastAggID  int64
+}
+
+// EventAggregator builds tick, volume, or dollar bars: a bar closes as
+// soon as its accumulated tick count, base volume, or dollar volume
+// reaches the threshold, so bars arrive faster when the market is active
+// and slower when it is quiet. The closing trade is included in the bar
+// it closes.
+type EventAggregator struct {
+	mode      BarMode
+	threshold float64
+
+	bars    []EventBar
+	current *EventBar
+	sumPQ   float64 // price*qty accumulator for the VWAP
+}
+
+// NewEventAggregator creates an event-bar aggregator. The threshold is a
+// trade count for tick bars, a base-asset quantity for volume bars, and a
+// quote-asset amount for dollar bars.
+func NewEventAggregator(mode BarMode, threshold float64) *EventAggregator {
+	return &EventAggregator{
+		mode:      mode,
+		threshold: threshold,
+	}
+}
+
+// ProcessTrades folds a batch of trades into the current bar, emitting
+// completed bars as thresholds are crossed. Trades must arrive in
+// chronological order.
+func (e *EventAggregator) ProcessTrades(trades []parser.Trade) {
+	for _, trade := range trades {
+		if e.current == nil {
+			e.current = &EventBar{
+				OpenTime:   trade.Time,
+				OpenPrice:  trade.Price,
+				HighPrice:  trade.Price,
+				LowPrice:   trade.Price,
+				FirstAggID: trade.AggID,
+			}
+			e.sumPQ = 0
+		}
+
+		bar := e.current
+		if trade.Price > bar.HighPrice {
+			bar.HighPrice = trade.Price
+		}
+		if trade.Price < bar.LowPrice {
+			bar.LowPrice = trade.Price
+		}
+		bar.ClosePrice = trade.Price
+		bar.CloseTime = trade.Time
+		bar.LastAggID = trade.AggID
+
+		bar.Volume += trade.Qty
+		bar.DollarVolume += trade.DollarVolume()
+		if trade.IsBuy() {
+			bar.BuyVolume += trade.Qty
+		} else {
+			bar.SellVolume += trade.Qty
+		}
+		bar.NTrades++
+		e.sumPQ += trade.Price * trade.Qty
+
+		if e.accumulated() >= e.threshold {
+			e.closeBar()
+		}
+	}
+}
+
+// accumulated returns the current bar's progress toward the threshold
+func (e *EventAggregator) accumulated() float64 {
+	switch e.mode {
+	case VolumeBars:
+		return e.current.Volume
+	case DollarBars:
+		return e.current.DollarVolume
+	default:
+		return float64(e.current.NTrades)
+	}
+}
+
+// closeBar finalizes the current bar and starts a fresh one
+func (e *EventAggregator) closeBar() {
+	bar := e.current
+	if bar.Volume > 0 {
+		bar.VWAP = e.sumPQ / bar.Volume
+	}
+	e.bars = append(e.bars, *bar)
+	e.current = nil
+}
+
+// Flush closes the partial trailing bar, if any. Call once at the end of
+// the stream.
+func (e *EventAggregator) Flush() {
+	if e.current != nil && e.current.NTrades > 0 {
+		e.closeBar()
+	}
+}
+
+// Bars returns the completed bars and clears the internal buffer, so
+// callers can drain incrementally between files
+func (e *EventAggregator) Bars() []EventBar {
+	bars := e.bars
+	e.bars = nil
+	return bars
+}
@@ -1,6 +1,7 @@
 package aggregator
 
 import (
+	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -27,6 +28,65 @@ type Aggregator struct {
 	// Track dates for warmup detection
 	firstDate string
 	lastDate  string
+
+	// Optional per-hour top-K largest trade tracking
+	topK      int
+	topTrades map[time.Time][]TradeRecord
+
+	// Hour-of-day seasonal volume tracking
+	seasonal *SeasonalTracker
+
+	// Lot-size regime tracking from qty quantization
+	lot *LotTracker
+
+	// Rolling print-signature cache for iceberg detection
+	iceberg *icebergTracker
+
+	// Per-trade empirical rank tracking (continuous whale intensity)
+	ranks bool
+
+	// Logical-order stitching before whale classification, with counters
+	// comparing stitched vs raw classification
+	stitch             bool
+	stitchedOrders     int64
+	stitchUpgradedP99  int64
+	stitchUpgradedP999 int64
+
+	// Compensated volume summation, with the largest naive-sum drift seen
+	// across finalized bars
+	precise     bool
+	maxSumDrift float64
+
+	// Early finalization of bars that have fallen behind the stream,
+	// bounding pendingBars growth (see SetFlushAfter)
+	flushAfter int
+	latestHour time.Time
+
+	// Extra whale percentile tiers beyond the built-in P99/P99.9, with
+	// their precomputed column labels (see SetWhaleTiers)
+	tiers      []float64
+	tierLabels []string
+
+	// Optional comparison detector running a second rolling window over
+	// the same stream, emitting its own whale column group (see
+	// SetCompareDetector)
+	compare       *whale.Detector
+	compareLabels [2]string
+
+	// Whale detection over USD notional instead of raw quantity (see
+	// EnableNotionalWhales)
+	notional bool
+
+	// Policy for trades arriving after their hour has finalized (see
+	// SetLatePolicy). closed records every finalized hour (bar pointers
+	// retained only under LateRevise); published marks the hours already
+	// handed to sinks, whose revisions need correction events.
+	latePolicy      LatePolicy
+	closed          map[time.Time]*HourlyBar
+	published       map[time.Time]bool
+	revised         map[time.Time]*HourlyBar
+	lateDropped     int64
+	lateCorrections int64
 }
 
 // NewAggregator creates a new hourly aggregator
@@ -36,7 +96,252 @@ func NewAggregator(windowDays, samplesPerDay int) *Aggregator {
 		windowDays:    windowDays,
 		pendingBars:   make(map[time.Time]*HourlyBar),
 		finalizedBars: make([]HourlyResult, 0),
+		seasonal:      newSeasonalTracker(),
+		lot:           newLotTracker(),
+		iceberg:       newIcebergTracker(),
+		closed:        make(map[time.Time]*HourlyBar),
+		published:     make(map[time.Time]bool),
+		revised:       make(map[time.Time]*HourlyBar),
+	}
+}
+
+// EnableWhaleRanks turns on per-trade empirical rank tracking, populating
+// the whale_rank_vw_mean and whale_rank_max columns
+func (a *Aggregator) EnableWhaleRanks() {
+	a.ranks = true
+}
+
+// EnableStitching turns on logical-order stitching: consecutive same-side
+// aggTrades that look like one split order are classified by their combined
+// quantity instead of each aggregate's own
+func (a *Aggregator) EnableStitching() {
+	a.stitch = true
+}
+
+// EnablePreciseSums makes every bar accumulate its volume sums with Kahan
+// compensated summation, eliminating float accumulation error over hours
+// with tens of millions of trades. The observed drift of the naive sums is
+// tracked for the self-check report (see MaxSumDrift).
+func (a *Aggregator) EnablePreciseSums() {
+	a.precise = true
+}
+
+// EnableNotionalWhales makes whale detection operate on USD notional
+// (price*qty) instead of raw quantity: the rolling reservoirs sample
+// notional and trades clear thresholds by their notional. Quantity
+// thresholds drift badly as price moves 10x over multi-year builds;
+// notional tiers stay comparable across periods. Must be chosen before
+// the first run of a build — resuming with the other mode would mix
+// units in the checkpointed reservoirs. Bar volume columns stay in base
+// quantity either way.
+func (a *Aggregator) EnableNotionalWhales() {
+	a.notional = true
+}
+
+// whaleSize is the magnitude whale detection operates on for a trade:
+// raw quantity, or USD notional in notional mode
+func (a *Aggregator) whaleSize(trade parser.Trade) float64 {
+	if a.notional {
+		return trade.Price * trade.Qty
+	}
+	return trade.Qty
+}
+
+// SetWhaleTiers adds extra whale percentile tiers beyond the built-in
+// P99/P99.9: each trade clearing a tier's rolling threshold is folded
+// into that tier's column group. Pass the tiers returned by
+// RegisterWhaleTiers so the registry and accumulators agree.
+func (a *Aggregator) SetWhaleTiers(tiers []float64) {
+	a.tiers = tiers
+	a.tierLabels = make([]string, len(tiers))
+	for i, pct := range tiers {
+		a.tierLabels[i] = TierLabel(pct)
+	}
+}
+
+// applyTiers classifies a trade against the extra whale tiers by its
+// whale size. No-op without configured tiers. Caller must hold a.mu.
+func (a *Aggregator) applyTiers(bar *HourlyBar, trade parser.Trade, size float64) {
+	for i, pct := range a.tiers {
+		if size >= a.detector.TierThreshold(trade.Date(), pct) {
+			bar.AddTierTrade(a.tierLabels[i], trade.Qty, trade.IsBuy())
+		}
+	}
+}
+
+// SetCompareDetector runs a second whale detector with its own rolling
+// window in parallel over the same trade stream, emitting P99/P99.9
+// column groups suffixed _w<days> alongside the primary ones. One pass
+// over the data then yields both parameterizations for sensitivity
+// studies. The comparison detector is not checkpointed: resumed runs
+// re-warm it from the resume point.
+func (a *Aggregator) SetCompareDetector(windowDays, samplesPerDay int) {
+	a.compare = whale.NewDetector(windowDays, samplesPerDay)
+	a.compareLabels = RegisterCompareColumns(windowDays)
+}
+
+// applyCompare classifies a trade against the comparison detector's
+// thresholds by its whale size. No-op without a comparison detector.
+// Caller must hold a.mu.
+func (a *Aggregator) applyCompare(bar *HourlyBar, trade parser.Trade, size float64) {
+	if a.compare == nil {
+		return
+	}
+	c := a.compare.ClassifySize(trade.Date(), size)
+	if c.IsWhaleP99 {
+		bar.AddTierTrade(a.compareLabels[0], trade.Qty, trade.IsBuy())
+	}
+	if c.IsWhaleP999 {
+		bar.AddTierTrade(a.compareLabels[1], trade.Qty, trade.IsBuy())
+	}
+}
+
+// SetFlushAfter makes the aggregator finalize pending bars more than k
+// hours older than the newest hour seen during processing, bounding
+// pendingBars growth on very long single-file processing. Safe because
+// trades are chronological within a file: an hour that far behind the
+// stream receives no more trades. Zero disables early finalization and
+// bars wait for Flush() or GetBarsForMonth() as before.
+func (a *Aggregator) SetFlushAfter(k int) {
+	a.flushAfter = k
+}
+
+// LatePolicy decides what happens to a trade that arrives after its hour
+// has already been finalized
+type LatePolicy int
+
+const (
+	// LateDrop discards late trades and counts them. Finalized bars are
+	// immutable: sinks see exactly one event per hour.
+	LateDrop LatePolicy = iota
+
+	// LateRevise folds late trades into the finalized bar, re-deriving
+	// its statistics. Bars already handed to sinks are re-published as
+	// correction events.
+	LateRevise
+)
+
+// ParseLatePolicy parses a late-data policy name
+func ParseLatePolicy(s string) (LatePolicy, error) {
+	switch s {
+	case "", "drop":
+		return LateDrop, nil
+	case "revise":
+		return LateRevise, nil
+	default:
+		return 0, fmt.Errorf("unknown late policy %q (expected drop or revise)", s)
+	}
+}
+
+// SetLatePolicy selects how trades arriving for already-finalized hours
+// are handled. The default is LateDrop. Under LateRevise the aggregator
+// retains a reference to every finalized bar so late trades can be
+// folded back in; under LateDrop only the set of closed hours is kept.
+func (a *Aggregator) SetLatePolicy(p LatePolicy) {
+	a.latePolicy = p
+}
+
+// noteClosed records that an hour's bar has finalized, so later trades
+// for it go through the late policy instead of opening a duplicate bar.
+// The bar pointer is retained only under LateRevise. Caller must hold a.mu.
+func (a *Aggregator) noteClosed(hour time.Time, bar *HourlyBar) {
+	if a.latePolicy == LateRevise {
+		a.closed[hour] = bar
+	} else {
+		a.closed[hour] = nil
+	}
+}
+
+// lateBar resolves a trade for an already-closed hour against the late
+// policy: nil means the trade is dropped (and counted), otherwise the
+// returned bar is the finalized bar to fold the trade into, marked for
+// revision. Caller must hold a.mu.
+func (a *Aggregator) lateBar(hour time.Time) *HourlyBar {
+	bar := a.closed[hour]
+	if a.latePolicy != LateRevise || bar == nil {
+		a.lateDropped++
+		return nil
 	}
+	a.revised[hour] = bar
+	return bar
+}
+
+// DrainRevised re-derives the statistics of every bar revised by late
+// trades since the last call and returns the ones that were already
+// published, sorted by time, so the caller can emit correction events
+// and rewrite their months' checkpoints. Revised bars not yet published
+// are fixed silently — the upcoming checkpoint saves them as usual.
+func (a *Aggregator) DrainRevised() []HourlyResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var corrections []HourlyResult
+	for hour, bar := range a.revised {
+		bar.Finalize()
+		if a.published[hour] {
+			bar.Revision++
+			a.lateCorrections++
+			corrections = append(corrections, HourlyResult{Time: hour, Bar: bar})
+		}
+	}
+	a.revised = make(map[time.Time]*HourlyBar)
+
+	sort.Slice(corrections, func(i, j int) bool {
+		return corrections[i].Time.Before(corrections[j].Time)
+	})
+	return corrections
+}
+
+// ClosedBarsForMonth returns the retained finalized bars of a month,
+// sorted by time, so a revised month's checkpoint can be rewritten.
+// Only populated under LateRevise.
+func (a *Aggregator) ClosedBarsForMonth(month string) []HourlyResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var result []HourlyResult
+	for hour, bar := range a.closed {
+		if bar != nil && hour.Format("2006-01") == month {
+			result = append(result, HourlyResult{Time: hour, Bar: bar})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+// LateStats returns how many late trades were dropped and how many bar
+// corrections were published
+func (a *Aggregator) LateStats() (dropped, corrections int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.lateDropped, a.lateCorrections
+}
+
+// MaxSumDrift returns the largest relative difference between naive and
+// compensated volume sums observed across all finalized bars. Zero unless
+// precise sums are enabled.
+func (a *Aggregator) MaxSumDrift() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.maxSumDrift
+}
+
+// noteDrift folds a just-finalized bar's self-check result into the run
+// maximum. Caller must hold a.mu.
+func (a *Aggregator) noteDrift(bar *HourlyBar) {
+	if bar.sumDrift > a.maxSumDrift {
+		a.maxSumDrift = bar.sumDrift
+	}
+}
+
+// StitchStats returns how many multi-trade logical orders were stitched and
+// how many trades were upgraded to whale status only because of stitching
+func (a *Aggregator) StitchStats() (orders, upgradedP99, upgradedP999 int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.stitchedOrders, a.stitchUpgradedP99, a.stitchUpgradedP999
 }
 
 // ProcessTrades processes a batch of trades
@@ -53,7 +358,11 @@ func (a *Aggregator) ProcessTrades(trades []parser.Trade) {
 	// First pass: add samples to whale detector to build percentile distribution
 	for _, trade := range trades {
 		date := trade.Date()
-		a.detector.AddSample(date, trade.Qty)
+		size := a.whaleSize(trade)
+		a.detector.AddSample(date, size)
+		if a.compare != nil {
+			a.compare.AddSample(date, size)
+		}
 
 		// Track date range
 		if a.firstDate == "" || date < a.firstDate {
@@ -64,18 +373,42 @@ func (a *Aggregator) ProcessTrades(trades []parser.Trade) {
 		}
 	}
 
+	// Optionally stitch split orders back together for classification
+	var stitchedQtys []float64
+	if a.stitch {
+		var orders int64
+		stitchedQtys, orders = stitchQtys(trades)
+		a.stitchedOrders += orders
+	}
+
 	// Second pass: classify trades and aggregate into hourly bars
 	// Now that all samples are added, thresholds are up-to-date
-	for _, trade := range trades {
+	for i, trade := range trades {
 		// Classify trade using rolling percentile thresholds
-		classification := a.detector.Classify(trade)
+		size := a.whaleSize(trade)
+		classification := a.detector.ClassifySize(trade.Date(), size)
+		if a.stitch {
+			classification = a.upgradeClassification(classification, trade.Date(), a.stitchedSize(trade, stitchedQtys[i]))
+		}
 
 		// Get or create hourly bar
 		hour := trade.Hour()
 		bar, exists := a.pendingBars[hour]
 		if !exists {
-			bar = NewHourlyBar()
-			a.pendingBars[hour] = bar
+			if _, wasClosed := a.closed[hour]; wasClosed {
+				if bar = a.lateBar(hour); bar == nil {
+					continue
+				}
+			} else {
+				bar = NewHourlyBar()
+				if a.precise {
+					bar.EnablePreciseSums()
+				}
+				a.pendingBars[hour] = bar
+			}
+		}
+		if hour.After(a.latestHour) {
+			a.latestHour = hour
 		}
 
 		// Add trade with proper whale classification
@@ -87,15 +420,58 @@ func (a *Aggregator) ProcessTrades(trades []parser.Trade) {
 			classification.IsWhaleP99,
 			classification.IsWhaleP999,
 		)
+		bar.RecordIDs(trade.AggID, trade.Fills())
+		a.applyTiers(bar, trade, size)
+		a.applyCompare(bar, trade, size)
+		if a.ranks {
+			bar.RecordRank(a.detector.Rank(trade.Date(), size), trade.Qty)
+		}
+		bar.IcebergPrints += a.iceberg.Add(trade)
+		a.seasonal.Add(trade.Date(), trade.Time.Hour(), trade.Qty)
+		a.lot.Add(trade.Date(), trade.Qty)
+
+		a.recordTopTrade(hour, trade)
 	}
 
 	// NOTE: We deliberately don't call tryFinalizePending() here.
 	// Finalizing during batch processing causes duplicates when more trades
 	// for the same hour arrive in later batches.
 	// Bars are finalized in GetBarsForMonth() at checkpoint time.
+	// The exception is the opt-in flushAfter policy, which only touches
+	// hours far enough behind the stream that they can't recur.
+	a.flushStale()
 }
 
 
+// stitchedSize converts a stitched logical-order quantity into the
+// detector's units: the quantity itself, or its notional at the trade's
+// price in notional mode (the order's legs print at near-identical
+// prices, so one leg's price stands in for all of them)
+func (a *Aggregator) stitchedSize(trade parser.Trade, orderQty float64) float64 {
+	if a.notional {
+		return trade.Price * orderQty
+	}
+	return orderQty
+}
+
+// upgradeClassification re-grades a trade by its stitched logical-order
+// size, counting the trades whose whale status is owed to stitching.
+// Caller must hold a.mu.
+func (a *Aggregator) upgradeClassification(raw whale.Classification, date string, orderSize float64) whale.Classification {
+	thresholds := a.detector.GetThresholds(date)
+	stitched := whale.Classification{
+		IsWhaleP99:  orderSize >= thresholds.P99,
+		IsWhaleP999: orderSize >= thresholds.P999,
+	}
+	if stitched.IsWhaleP99 && !raw.IsWhaleP99 {
+		a.stitchUpgradedP99++
+	}
+	if stitched.IsWhaleP999 && !raw.IsWhaleP999 {
+		a.stitchUpgradedP999++
+	}
+	return stitched
+}
+
 // ProcessTradesWithClassification processes trades with immediate whale classification
 // Use this for the second pass when thresholds are already known
 func (a *Aggregator) ProcessTradesWithClassification(trades []parser.Trade) {
@@ -106,15 +482,49 @@ func (a *Aggregator) ProcessTradesWithClassification(trades []parser.Trade) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	// Optionally stitch split orders back together for classification
+	var stitchedQtys []float64
+	if a.stitch {
+		var orders int64
+		stitchedQtys, orders = stitchQtys(trades)
+		a.stitchedOrders += orders
+	}
+
 	// Classify all trades
-	classifications := a.detector.ClassifyBatch(trades)
+	var classifications []whale.Classification
+	if a.notional {
+		classifications = make([]whale.Classification, len(trades))
+		for i, trade := range trades {
+			classifications[i] = a.detector.ClassifySize(trade.Date(), a.whaleSize(trade))
+		}
+	} else {
+		classifications = a.detector.ClassifyBatch(trades)
+	}
+	if a.stitch {
+		for i, trade := range trades {
+			classifications[i] = a.upgradeClassification(classifications[i], trade.Date(), a.stitchedSize(trade, stitchedQtys[i]))
+		}
+	}
 
 	for i, trade := range trades {
+		size := a.whaleSize(trade)
 		hour := trade.Hour()
 		bar, exists := a.pendingBars[hour]
 		if !exists {
-			bar = NewHourlyBar()
-			a.pendingBars[hour] = bar
+			if _, wasClosed := a.closed[hour]; wasClosed {
+				if bar = a.lateBar(hour); bar == nil {
+					continue
+				}
+			} else {
+				bar = NewHourlyBar()
+				if a.precise {
+					bar.EnablePreciseSums()
+				}
+				a.pendingBars[hour] = bar
+			}
+		}
+		if hour.After(a.latestHour) {
+			a.latestHour = hour
 		}
 
 		bar.AddTrade(
@@ -125,6 +535,42 @@ func (a *Aggregator) ProcessTradesWithClassification(trades []parser.Trade) {
 			classifications[i].IsWhaleP99,
 			classifications[i].IsWhaleP999,
 		)
+		bar.RecordIDs(trade.AggID, trade.Fills())
+		a.applyTiers(bar, trade, size)
+		a.applyCompare(bar, trade, size)
+		if a.ranks {
+			bar.RecordRank(a.detector.Rank(trade.Date(), size), trade.Qty)
+		}
+		bar.IcebergPrints += a.iceberg.Add(trade)
+		a.seasonal.Add(trade.Date(), trade.Time.Hour(), trade.Qty)
+		a.lot.Add(trade.Date(), trade.Qty)
+	}
+
+	a.flushStale()
+}
+
+// flushStale finalizes pending bars that have fallen more than flushAfter
+// hours behind the newest hour seen, moving them to finalizedBars where
+// GetBarsForMonth() still picks them up. No-op unless SetFlushAfter was
+// called. Caller must hold a.mu.
+func (a *Aggregator) flushStale() {
+	if a.flushAfter <= 0 || a.latestHour.IsZero() {
+		return
+	}
+	cutoff := a.latestHour.Add(-time.Duration(a.flushAfter) * time.Hour)
+	for hour, bar := range a.pendingBars {
+		if hour.Before(cutoff) {
+			a.applySeasonal(hour, bar)
+			a.applyLot(hour, bar)
+			bar.Finalize()
+			a.noteDrift(bar)
+			a.finalizedBars = append(a.finalizedBars, HourlyResult{
+				Time: hour,
+				Bar:  bar,
+			})
+			a.noteClosed(hour, bar)
+			delete(a.pendingBars, hour)
+		}
 	}
 }
 
@@ -146,7 +592,10 @@ func (a *Aggregator) Flush() {
 	// Finalize all remaining
 	for _, hour := range hours {
 		bar := a.pendingBars[hour]
+		a.applySeasonal(hour, bar)
+		a.applyLot(hour, bar)
 		bar.Finalize()
+		a.noteDrift(bar)
 
 		a.finalizedBars = append(a.finalizedBars, HourlyResult{
 			Time: hour,
@@ -178,7 +627,10 @@ func (a *Aggregator) GetAllBars() []HourlyResult {
 	all = append(all, a.finalizedBars...)
 
 	for hour, bar := range a.pendingBars {
+		a.applySeasonal(hour, bar)
+		a.applyLot(hour, bar)
 		bar.Finalize()
+		a.noteDrift(bar)
 		all = append(all, HourlyResult{
 			Time: hour,
 			Bar:  bar,
@@ -226,6 +678,12 @@ func (a *Aggregator) Detector() *whale.Detector {
 	return a.detector
 }
 
+// CompareDetector returns the comparison detector for state
+// export/import, or nil when no comparison window is configured.
+func (a *Aggregator) CompareDetector() *whale.Detector {
+	return a.compare
+}
+
 // GetBarsForMonth returns all bars (pending + finalized) for a specific month.
 // Pending bars are finalized before being returned. Returned bars are removed from internal state.
 func (a *Aggregator) GetBarsForMonth(month string) []HourlyResult {
@@ -248,7 +706,10 @@ func (a *Aggregator) GetBarsForMonth(month string) []HourlyResult {
 	// Collect from pendingBars
 	for hour, bar := range a.pendingBars {
 		if hour.Format("2006-01") == month {
+			a.applySeasonal(hour, bar)
+			a.applyLot(hour, bar)
 			bar.Finalize()
+			a.noteDrift(bar)
 			result = append(result, HourlyResult{
 				Time: hour,
 				Bar:  bar,
@@ -257,6 +718,13 @@ func (a *Aggregator) GetBarsForMonth(month string) []HourlyResult {
 		}
 	}
 
+	// These bars are about to be checkpointed and handed to sinks: any
+	// trade for them from here on goes through the late policy
+	for _, hr := range result {
+		a.noteClosed(hr.Time, hr.Bar)
+		a.published[hr.Time] = true
+	}
+
 	// Sort by time
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Time.Before(result[j].Time)
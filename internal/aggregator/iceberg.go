@@ -0,0 +1,82 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/clement/aggtrades/internal/parser"
+)
+
+const (
+	// icebergWindow is how long a (price, qty) pair stays live: repeats
+	// further apart are refills of the book, not one resting order
+	icebergWindow = 60 * time.Second
+
+	// icebergMinRepeats is how many identical prints at the same price
+	// must occur inside the window before they count as an iceberg
+	icebergMinRepeats = 3
+
+	// icebergPruneEvery bounds how often the cache drops stale pairs
+	icebergPruneEvery = 10 * time.Second
+)
+
+// icebergKey identifies a print signature: an iceberg order keeps
+// refilling the same visible quantity at the same price
+type icebergKey struct {
+	price float64
+	qty   float64
+}
+
+type icebergEntry struct {
+	count int
+	last  time.Time
+}
+
+// icebergTracker keeps a small rolling cache of recent print signatures
+// and flags the classic iceberg pattern: repeated identical-size prints at
+// the same price within a short window
+type icebergTracker struct {
+	recent    map[icebergKey]*icebergEntry
+	lastPrune time.Time
+}
+
+func newIcebergTracker() *icebergTracker {
+	return &icebergTracker{recent: make(map[icebergKey]*icebergEntry)}
+}
+
+// Add records one print and returns how many prints this one newly
+// qualifies as iceberg prints: 0 until the signature repeats
+// icebergMinRepeats times, then the whole run retroactively, then 1 per
+// further repeat while the signature stays live
+func (it *icebergTracker) Add(trade parser.Trade) int64 {
+	it.prune(trade.Time)
+
+	key := icebergKey{price: trade.Price, qty: trade.Qty}
+	entry, ok := it.recent[key]
+	if !ok || trade.Time.Sub(entry.last) > icebergWindow {
+		it.recent[key] = &icebergEntry{count: 1, last: trade.Time}
+		return 0
+	}
+
+	entry.count++
+	entry.last = trade.Time
+
+	switch {
+	case entry.count == icebergMinRepeats:
+		return icebergMinRepeats
+	case entry.count > icebergMinRepeats:
+		return 1
+	}
+	return 0
+}
+
+func (it *icebergTracker) prune(now time.Time) {
+	if now.Sub(it.lastPrune) < icebergPruneEvery {
+		return
+	}
+	it.lastPrune = now
+	for key, entry := range it.recent {
+		if now.Sub(entry.last) > icebergWindow {
+			delete(it.recent, key)
+		}
+	}
+}
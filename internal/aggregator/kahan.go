@@ -0,0 +1,54 @@
+package aggregator
+
+import "math"
+
+// kahanSum is a compensated (Kahan) accumulator: over hours with tens of
+// millions of trades, naive float64 summation loses low-order bits; the
+// compensation term carries them forward.
+type kahanSum struct {
+	sum float64
+	c   float64
+}
+
+func (k *kahanSum) add(v float64) {
+	y := v - k.c
+	t := k.sum + y
+	k.c = (t - k.sum) - y
+	k.sum = t
+}
+
+// preciseSums shadows the hour's main volume accumulators with compensated
+// summation. When enabled, the compensated values become authoritative at
+// Finalize and the drift vs the naive sums is recorded for the self-check
+// report.
+type preciseSums struct {
+	buyVol        kahanSum
+	sellVol       kahanSum
+	buyVolUSD     kahanSum
+	sellVolUSD    kahanSum
+	sumPriceQty   kahanSum
+	sumQty        kahanSum
+	sumPriceSqQty kahanSum
+}
+
+func (p *preciseSums) add(price, qty, dollarVol float64, isBuy bool) {
+	if isBuy {
+		p.buyVol.add(qty)
+		p.buyVolUSD.add(dollarVol)
+	} else {
+		p.sellVol.add(qty)
+		p.sellVolUSD.add(dollarVol)
+	}
+	p.sumPriceQty.add(price * qty)
+	p.sumQty.add(qty)
+	p.sumPriceSqQty.add(price * price * qty)
+}
+
+// relDrift returns |naive-compensated| / |compensated| (0 when the
+// compensated sum is 0)
+func relDrift(naive, compensated float64) float64 {
+	if compensated == 0 {
+		return 0
+	}
+	return math.Abs(naive-compensated) / math.Abs(compensated)
+}
@@ -0,0 +1,109 @@
+package aggregator
+
+import (
+	"sort"
+	"time"
+)
+
+// lotRegimeFactor is the step-change ratio that starts a new regime. Lot
+// size changes on exchanges are typically a full order of magnitude, so a
+// conservative 5x avoids false regime breaks from noise.
+const lotRegimeFactor = 5.0
+
+// LotTracker detects lot-size regime changes from qty quantization: the
+// smallest quantity traded on a day approximates the lot step, and a large
+// jump in that step between days marks a new regime. Whale threshold
+// comparisons across regimes are misleading, so bars carry the regime id.
+type LotTracker struct {
+	minQty  map[string]float64 // date (YYYY-MM-DD) -> smallest qty seen
+	regimes map[string]int64   // date -> assigned regime id
+	step    float64            // current regime's lot step
+	current int64              // current regime id (first regime is 1)
+}
+
+func newLotTracker() *LotTracker {
+	return &LotTracker{
+		minQty:  make(map[string]float64),
+		regimes: make(map[string]int64),
+	}
+}
+
+// Add tracks the smallest positive quantity seen on a date
+func (l *LotTracker) Add(date string, qty float64) {
+	if qty <= 0 {
+		return
+	}
+	if min, ok := l.minQty[date]; !ok || qty < min {
+		l.minQty[date] = qty
+	}
+}
+
+// Regime returns the regime id for a date, assigning ids to all not yet
+// assigned dates up to it in chronological order. Bars finalize in order,
+// so the assignment stays stable.
+func (l *LotTracker) Regime(date string) int64 {
+	if id, ok := l.regimes[date]; ok {
+		return id
+	}
+
+	pending := make([]string, 0)
+	for d := range l.minQty {
+		if _, ok := l.regimes[d]; !ok && d <= date {
+			pending = append(pending, d)
+		}
+	}
+	sort.Strings(pending)
+
+	for _, d := range pending {
+		step := l.minQty[d]
+		switch {
+		case l.current == 0:
+			l.current = 1
+			l.step = step
+		case step >= l.step*lotRegimeFactor || step <= l.step/lotRegimeFactor:
+			l.current++
+			l.step = step
+		}
+		l.regimes[d] = l.current
+	}
+
+	if id, ok := l.regimes[date]; ok {
+		return id
+	}
+	return l.current
+}
+
+// LotState is the tracker's serializable form
+type LotState struct {
+	MinQty  map[string]float64 `json:"min_qty"`
+	Regimes map[string]int64   `json:"regimes"`
+	Step    float64            `json:"step"`
+	Current int64              `json:"current"`
+}
+
+// Export returns the tracker state in a JSON-friendly form
+func (l *LotTracker) Export() LotState {
+	return LotState{MinQty: l.minQty, Regimes: l.regimes, Step: l.step, Current: l.current}
+}
+
+// Import restores tracker state exported by Export
+func (l *LotTracker) Import(s LotState) {
+	if s.MinQty != nil {
+		l.minQty = s.MinQty
+	}
+	if s.Regimes != nil {
+		l.regimes = s.Regimes
+	}
+	l.step = s.Step
+	l.current = s.Current
+}
+
+// Lot returns the lot tracker for state export/import.
+func (a *Aggregator) Lot() *LotTracker {
+	return a.lot
+}
+
+// applyLot fills a bar's lot-size regime column just before finalization
+func (a *Aggregator) applyLot(hour time.Time, bar *HourlyBar) {
+	bar.LotSizeRegime = a.lot.Regime(hour.Format("2006-01-02"))
+}
@@ -0,0 +1,316 @@
+package aggregator
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ColumnType is the storage type of an output column
+type ColumnType int
+
+const (
+	ColumnFloat64 ColumnType = iota
+	ColumnInt64
+)
+
+// Column describes one output column of an hourly bar: its output name, the
+// HourlyBar field backing it, its JSON checkpoint key, storage type,
+// nullability, and feature group. The registry below is the single source of
+// truth shared by all sinks (parquet, checkpoint JSON, future CSV/JSONL/DB)
+// and by gap interpolation, so adding a field to HourlyBar needs exactly one
+// new entry here.
+type Column struct {
+	Name     string // output column name (snake_case)
+	Field    string // HourlyBar struct field
+	ShortKey string // key used in checkpoint JSON
+	Type     ColumnType
+	Nullable bool   // may be NaN/null (e.g. price stats of empty hours)
+	Group    string // feature group: flow, whale, intra, quote, price
+}
+
+// Columns lists all output columns in canonical order. The time column is
+// not part of the registry; every sink writes it first.
+var Columns = []Column{
+	{"buy_vol", "BuyVol", "bv", ColumnFloat64, false, "flow"},
+	{"sell_vol", "SellVol", "sv", ColumnFloat64, false, "flow"},
+	{"n_trades", "NTrades", "nt", ColumnInt64, false, "flow"},
+	{"buy_count", "BuyCount", "bc", ColumnInt64, false, "flow"},
+	{"sell_count", "SellCount", "sc", ColumnInt64, false, "flow"},
+	{"whale_buy_vol_p99", "WhaleBuyVolP99", "wbv99", ColumnFloat64, false, "whale"},
+	{"whale_sell_vol_p99", "WhaleSellVolP99", "wsv99", ColumnFloat64, false, "whale"},
+	{"whale_buy_count_p99", "WhaleBuyCountP99", "wbc99", ColumnInt64, false, "whale"},
+	{"whale_sell_count_p99", "WhaleSellCountP99", "wsc99", ColumnInt64, false, "whale"},
+	{"whale_buy_vol_p999", "WhaleBuyVolP999", "wbv999", ColumnFloat64, false, "whale"},
+	{"whale_sell_vol_p999", "WhaleSellVolP999", "wsv999", ColumnFloat64, false, "whale"},
+	{"whale_buy_count_p999", "WhaleBuyCountP999", "wbc999", ColumnInt64, false, "whale"},
+	{"whale_sell_count_p999", "WhaleSellCountP999", "wsc999", ColumnInt64, false, "whale"},
+	{"iceberg_prints", "IcebergPrints", "icp", ColumnInt64, false, "whale"},
+	{"round_size_vol_ratio", "RoundSizeVolRatio", "rsvr", ColumnFloat64, false, "whale"},
+	{"round_notional_vol_ratio", "RoundNotionalVolRatio", "rnvr", ColumnFloat64, false, "whale"},
+	{"whale_rank_vw_mean", "WhaleRankVWMean", "wrvm", ColumnFloat64, true, "whale"},
+	{"whale_rank_max", "WhaleRankMax", "wrmx", ColumnFloat64, true, "whale"},
+	{"vol_first_30min", "VolFirst30Min", "vf30", ColumnFloat64, false, "intra"},
+	{"vol_last_30min", "VolLast30Min", "vl30", ColumnFloat64, false, "intra"},
+	{"vol_minute_skew", "VolMinuteSkew", "vms", ColumnFloat64, false, "intra"},
+	{"vol_peak_5m_ratio", "VolPeak5mRatio", "vp5", ColumnFloat64, false, "intra"},
+	{"buy_vol_usd", "BuyVolUSD", "bvu", ColumnFloat64, false, "quote"},
+	{"sell_vol_usd", "SellVolUSD", "svu", ColumnFloat64, false, "quote"},
+	{"first_agg_id", "FirstAggID", "fid", ColumnInt64, false, "continuity"},
+	{"last_agg_id", "LastAggID", "lid", ColumnInt64, false, "continuity"},
+	{"missing_id_count", "MissingIDCount", "mid", ColumnInt64, false, "continuity"},
+	{"avg_fills_per_aggtrade", "AvgFillsPerAggTrade", "afa", ColumnFloat64, false, "continuity"},
+	{"lot_size_regime", "LotSizeRegime", "lsr", ColumnInt64, false, "continuity"},
+	{"gap_hours_prev_24h", "GapHoursPrev24h", "gh24", ColumnInt64, false, "continuity"},
+	{"data_quality_score", "DataQualityScore", "dqs", ColumnFloat64, false, "continuity"},
+	{"source_rest", "SourceRest", "srt", ColumnInt64, false, "continuity"},
+	{"source", "Source", "src", ColumnInt64, false, "continuity"},
+	{"seasonal_hour_vol", "SeasonalHourVol", "shv", ColumnFloat64, true, "seasonal"},
+	{"seasonal_vol_ratio", "SeasonalVolRatio", "svr", ColumnFloat64, true, "seasonal"},
+	{"max_trade_size", "MaxTradeSize", "mts", ColumnFloat64, false, "price"},
+	{"poc_price", "PocPrice", "poc", ColumnFloat64, true, "price"},
+	{"vol_concentration", "VolConcentration", "vcon", ColumnFloat64, false, "price"},
+	{"vwap", "VWAP", "vwap", ColumnFloat64, true, "price"},
+	{"price_std", "PriceStd", "pstd", ColumnFloat64, true, "price"},
+	{"open_price", "OpenPrice", "opn", ColumnFloat64, true, "price"},
+	{"high_price", "HighPrice", "hgh", ColumnFloat64, true, "price"},
+	{"low_price", "LowPrice", "lwp", ColumnFloat64, true, "price"},
+	{"close_price", "ClosePrice", "cls", ColumnFloat64, true, "price"},
+}
+
+// tierFieldPrefix marks a virtual Field backed by the bar's extra-tier
+// maps instead of a struct field, which reflection can't reach. The
+// format is "tier:<stat>:<label>" with stat one of bv/sv/bc/sc.
+const tierFieldPrefix = "tier:"
+
+// tierField splits a virtual tier field into its stat and label
+func tierField(field string) (stat, label string, ok bool) {
+	if !strings.HasPrefix(field, tierFieldPrefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(field, tierFieldPrefix), ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// TierLabel formats a percentile tier as a column suffix, matching the
+// built-in convention: 95 → p95, 99.5 → p995
+func TierLabel(pct float64) string {
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(pct, 'f', -1, 64), ".", "")
+}
+
+// TierFromLabel parses a column suffix back into its percentile: p95 →
+// 95, p995 → 99.5. The decimal point goes after the first two digits —
+// tiers below 10 aren't meaningful — making this the inverse of
+// TierLabel for any realistic tier.
+func TierFromLabel(label string) (float64, bool) {
+	digits := strings.TrimPrefix(label, "p")
+	if digits == label || digits == "" {
+		return 0, false
+	}
+	if _, err := strconv.Atoi(digits); err != nil {
+		return 0, false
+	}
+	if len(digits) > 2 {
+		digits = digits[:2] + "." + digits[2:]
+	}
+	pct, err := strconv.ParseFloat(digits, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pct, true
+}
+
+// RegisterWhaleTiers appends a whale column group (buy/sell volume and
+// count) per extra percentile tier to the registry and returns the tiers
+// the aggregator should accumulate. The built-in P99/P99.9 tiers are
+// skipped — their columns are already present — and re-registration is
+// idempotent, so multi-symbol runs can call this once per pipeline. Must
+// be called before any sink reads Columns.
+func RegisterWhaleTiers(tiers []float64) []float64 {
+	var accumulate []float64
+	for _, pct := range tiers {
+		if pct == 99 || pct == 99.9 {
+			continue
+		}
+		accumulate = append(accumulate, pct)
+
+		label := TierLabel(pct)
+		if _, ok := ColumnByName("whale_buy_vol_" + label); ok {
+			continue
+		}
+		short := strings.TrimPrefix(label, "p")
+		Columns = append(Columns,
+			Column{"whale_buy_vol_" + label, tierFieldPrefix + "bv:" + label, "wbv" + short, ColumnFloat64, false, "whale"},
+			Column{"whale_sell_vol_" + label, tierFieldPrefix + "sv:" + label, "wsv" + short, ColumnFloat64, false, "whale"},
+			Column{"whale_buy_count_" + label, tierFieldPrefix + "bc:" + label, "wbc" + short, ColumnInt64, false, "whale"},
+			Column{"whale_sell_count_" + label, tierFieldPrefix + "sc:" + label, "wsc" + short, ColumnInt64, false, "whale"},
+		)
+	}
+	return accumulate
+}
+
+// CompareLabel formats the bar-map label for a comparison detector's
+// tier: p99 at a 30-day window → p99_w30. The resulting column names
+// (whale_buy_vol_p99_w30, ...) mirror the built-in group.
+func CompareLabel(tier string, windowDays int) string {
+	return tier + "_w" + strconv.Itoa(windowDays)
+}
+
+// CompareFromLabel parses a comparison column suffix back into its
+// window: p99_w30 → 30. Only the built-in p99/p999 tiers are emitted by
+// comparison runs, so anything else fails.
+func CompareFromLabel(label string) (windowDays int, ok bool) {
+	tier, days, found := strings.Cut(label, "_w")
+	if !found || (tier != "p99" && tier != "p999") {
+		return 0, false
+	}
+	n, err := strconv.Atoi(days)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// RegisterCompareColumns appends the whale column groups a comparison
+// detector emits — P99 and P99.9 classified against a second rolling
+// window — suffixed with the window length, and returns the bar-map
+// labels to accumulate under. Idempotent like RegisterWhaleTiers; must
+// be called before any sink reads Columns.
+func RegisterCompareColumns(windowDays int) [2]string {
+	labels := [2]string{CompareLabel("p99", windowDays), CompareLabel("p999", windowDays)}
+	for _, label := range labels {
+		if _, ok := ColumnByName("whale_buy_vol_" + label); ok {
+			continue
+		}
+		short := strings.TrimPrefix(strings.ReplaceAll(label, "_w", "w"), "p")
+		Columns = append(Columns,
+			Column{"whale_buy_vol_" + label, tierFieldPrefix + "bv:" + label, "wbv" + short, ColumnFloat64, false, "whale"},
+			Column{"whale_sell_vol_" + label, tierFieldPrefix + "sv:" + label, "wsv" + short, ColumnFloat64, false, "whale"},
+			Column{"whale_buy_count_" + label, tierFieldPrefix + "bc:" + label, "wbc" + short, ColumnInt64, false, "whale"},
+			Column{"whale_sell_count_" + label, tierFieldPrefix + "sc:" + label, "wsc" + short, ColumnInt64, false, "whale"},
+		)
+	}
+	return labels
+}
+
+// tierValue reads a tier column from the bar's maps (missing keys read
+// as zero, like untouched struct fields)
+func tierValue(b *HourlyBar, stat, label string) any {
+	switch stat {
+	case "bv":
+		return b.TierBuyVol[label]
+	case "sv":
+		return b.TierSellVol[label]
+	case "bc":
+		return b.TierBuyCount[label]
+	default:
+		return b.TierSellCount[label]
+	}
+}
+
+// tierSet writes a tier column into the bar's maps, accepting float64 or
+// int64 like Set
+func tierSet(b *HourlyBar, stat, label string, v any) {
+	b.ensureTiers()
+	asFloat := func() float64 {
+		switch x := v.(type) {
+		case float64:
+			return x
+		case int64:
+			return float64(x)
+		}
+		return 0
+	}
+	switch stat {
+	case "bv":
+		b.TierBuyVol[label] = asFloat()
+	case "sv":
+		b.TierSellVol[label] = asFloat()
+	case "bc":
+		b.TierBuyCount[label] = int64(asFloat())
+	default:
+		b.TierSellCount[label] = int64(asFloat())
+	}
+}
+
+// ColumnByName looks up a registry column by its output name
+func ColumnByName(name string) (Column, bool) {
+	for _, c := range Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Value reads the column's value from a bar (float64 or int64)
+func (c Column) Value(b *HourlyBar) any {
+	if stat, label, ok := tierField(c.Field); ok {
+		return tierValue(b, stat, label)
+	}
+	f := reflect.ValueOf(b).Elem().FieldByName(c.Field)
+	switch c.Type {
+	case ColumnInt64:
+		return f.Int()
+	default:
+		return f.Float()
+	}
+}
+
+// Set writes a value into the column's field of a bar. Accepts float64 or
+// int64 (JSON decoding yields float64 for both).
+func (c Column) Set(b *HourlyBar, v any) {
+	if stat, label, ok := tierField(c.Field); ok {
+		tierSet(b, stat, label, v)
+		return
+	}
+	f := reflect.ValueOf(b).Elem().FieldByName(c.Field)
+	switch c.Type {
+	case ColumnInt64:
+		switch x := v.(type) {
+		case int64:
+			f.SetInt(x)
+		case float64:
+			f.SetInt(int64(x))
+		}
+	default:
+		switch x := v.(type) {
+		case float64:
+			f.SetFloat(x)
+		case int64:
+			f.SetFloat(float64(x))
+		}
+	}
+}
+
+// Lerp writes the linear interpolation between prev and next into dst for
+// this column (integer columns are truncated)
+func (c Column) Lerp(dst, prev, next *HourlyBar, weight float64) {
+	if stat, label, ok := tierField(c.Field); ok {
+		var a, b float64
+		switch pv := tierValue(prev, stat, label).(type) {
+		case int64:
+			a, b = float64(pv), float64(tierValue(next, stat, label).(int64))
+			tierSet(dst, stat, label, int64(a+weight*(b-a)))
+		case float64:
+			a, b = pv, tierValue(next, stat, label).(float64)
+			tierSet(dst, stat, label, a+weight*(b-a))
+		}
+		return
+	}
+	pf := reflect.ValueOf(prev).Elem().FieldByName(c.Field)
+	nf := reflect.ValueOf(next).Elem().FieldByName(c.Field)
+	df := reflect.ValueOf(dst).Elem().FieldByName(c.Field)
+
+	switch c.Type {
+	case ColumnInt64:
+		a, b := float64(pf.Int()), float64(nf.Int())
+		df.SetInt(int64(a + weight*(b-a)))
+	default:
+		a, b := pf.Float(), nf.Float()
+		df.SetFloat(a + weight*(b-a))
+	}
+}
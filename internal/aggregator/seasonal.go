@@ -0,0 +1,106 @@
+package aggregator
+
+import "time"
+
+const (
+	// seasonalWindowDays is the rolling window for hour-of-day averages
+	seasonalWindowDays = 30
+
+	// seasonalPruneDays is how far back per-date volumes are kept
+	seasonalPruneDays = 45
+)
+
+// SeasonalTracker maintains per-date, per-hour-of-day volume totals so bars
+// can be normalized against the rolling 30-day average for their hour slot.
+// State survives month boundaries and is persisted with the checkpoint.
+type SeasonalTracker struct {
+	volumes map[string]*[24]float64 // date (YYYY-MM-DD) -> volume per hour-of-day
+	latest  string
+}
+
+func newSeasonalTracker() *SeasonalTracker {
+	return &SeasonalTracker{volumes: make(map[string]*[24]float64)}
+}
+
+// Add accumulates one trade's volume into its date and hour-of-day slot
+func (s *SeasonalTracker) Add(date string, hour int, qty float64) {
+	day, ok := s.volumes[date]
+	if !ok {
+		day = &[24]float64{}
+		s.volumes[date] = day
+		if date > s.latest {
+			s.latest = date
+			s.prune()
+		}
+	}
+	day[hour] += qty
+}
+
+// Average returns the mean volume for the given hour-of-day over the 30
+// days strictly before day, or 0 if no history exists yet
+func (s *SeasonalTracker) Average(day time.Time, hour int) float64 {
+	var sum float64
+	var n int
+	for d := 1; d <= seasonalWindowDays; d++ {
+		date := day.AddDate(0, 0, -d).Format("2006-01-02")
+		if v, ok := s.volumes[date]; ok {
+			sum += v[hour]
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// prune drops dates too old to ever fall inside the rolling window
+func (s *SeasonalTracker) prune() {
+	latest, err := time.Parse("2006-01-02", s.latest)
+	if err != nil {
+		return
+	}
+	cutoff := latest.AddDate(0, 0, -seasonalPruneDays).Format("2006-01-02")
+	for date := range s.volumes {
+		if date < cutoff {
+			delete(s.volumes, date)
+		}
+	}
+}
+
+// Export returns the tracker state in a JSON-friendly form
+func (s *SeasonalTracker) Export() map[string][]float64 {
+	out := make(map[string][]float64, len(s.volumes))
+	for date, day := range s.volumes {
+		out[date] = append([]float64(nil), day[:]...)
+	}
+	return out
+}
+
+// Import restores tracker state exported by Export
+func (s *SeasonalTracker) Import(volumes map[string][]float64) {
+	for date, vols := range volumes {
+		day := &[24]float64{}
+		copy(day[:], vols)
+		s.volumes[date] = day
+		if date > s.latest {
+			s.latest = date
+		}
+	}
+	s.prune()
+}
+
+// Seasonal returns the seasonal tracker for state export/import.
+func (a *Aggregator) Seasonal() *SeasonalTracker {
+	return a.seasonal
+}
+
+// applySeasonal fills a bar's seasonal normalization columns just before
+// finalization
+func (a *Aggregator) applySeasonal(hour time.Time, bar *HourlyBar) {
+	avg := a.seasonal.Average(hour, hour.Hour())
+	bar.SeasonalHourVol = avg
+	if avg > 0 {
+		bar.SeasonalVolRatio = bar.TotalVolume() / avg
+	}
+}
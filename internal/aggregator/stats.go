@@ -4,6 +4,15 @@ import (
 	"math"
 )
 
+// Source codes for the per-bar provenance column, ordered so that in a
+// merge the more unusual origin wins
+const (
+	SourceMonthlyZip   int64 = iota // aggregated from a monthly dump file
+	SourceREST                      // built from the REST aggTrades endpoint
+	SourceInterpolated              // synthesized by small-gap interpolation
+	SourceUnfilled                  // placeholder bar for an unfilled gap
+)
+
 // HourlyBar represents aggregated data for one hour
 type HourlyBar struct {
 	// Basic volumes
@@ -27,6 +36,19 @@ type HourlyBar struct {
 	WhaleBuyCountP999 int64
 	WhaleSellCountP999 int64
 
+	// Extra whale tiers beyond the built-in P99/P99.9, keyed by tier
+	// label (e.g. "p95"); nil unless extra tiers are configured
+	// (see RegisterWhaleTiers)
+	TierBuyVol    map[string]float64
+	TierSellVol   map[string]float64
+	TierBuyCount  map[string]int64
+	TierSellCount map[string]int64
+
+	// Revision counts how many correction events have been published for
+	// this bar after late trades revised it under the revise policy. Not
+	// an output column; zero on first publication.
+	Revision int
+
 	// Intra-hour distribution
 	VolFirst30Min float64
 	VolLast30Min  float64
@@ -40,10 +62,99 @@ type HourlyBar struct {
 	VWAP         float64
 	PriceStd     float64
 
+	// OHLC: first and last trade price of the hour plus its extremes.
+	// Zero (not NaN) for hours without trades, like VWAP.
+	OpenPrice  float64
+	HighPrice  float64
+	LowPrice   float64
+	ClosePrice float64
+
+	// Aggregate trade ID continuity
+	FirstAggID     int64
+	LastAggID      int64
+	MissingIDCount int64
+
+	// Mean individual fills per aggregate trade (sweep detection)
+	AvgFillsPerAggTrade float64
+
+	// Lot-size regime id (increments when the qty quantization step jumps)
+	LotSizeRegime int64
+
+	// Data quality, set during gap filling: missing hours among the
+	// previous 24, and a 0..1 score (1 observed, 0.5 low activity,
+	// 0.25 interpolated, 0 unfilled)
+	GapHoursPrev24h  int64
+	DataQualityScore float64
+
+	// 1 when the hour was built from the REST aggTrades endpoint (batch
+	// tail top-up) instead of the dump files
+	SourceRest int64
+
+	// Source is the bar's provenance, one of the Source* codes (monthly
+	// dump, REST top-up, interpolated, unfilled). Finer-grained than
+	// SourceRest, which it subsumes but keeps for compatibility.
+	Source int64
+
+	// Hour-of-day seasonal normalization: rolling 30-day average volume
+	// for this hour slot, and this bar's ratio to it
+	SeasonalHourVol  float64
+	SeasonalVolRatio float64
+
+	// Prints matching the iceberg signature (repeated identical size at
+	// the same price within a short window)
+	IcebergPrints int64
+
+	// Share of volume printed at round sizes (multiples of 0.5 base
+	// units) or round notionals (multiples of $100k), a footprint of
+	// manual and whale activity
+	RoundSizeVolRatio     float64
+	RoundNotionalVolRatio float64
+
+	// Lite volume profile: the price of the busiest 10bp price bin
+	// (point of control) and its share of the hour's volume
+	PocPrice         float64
+	VolConcentration float64
+
+	// Intra-hour volume shape: volume-weighted skewness of the minute
+	// distribution (negative = front-loaded, positive = back-loaded) and
+	// the share of volume in the busiest 5-minute window
+	VolMinuteSkew  float64
+	VolPeak5mRatio float64
+
+	// Continuous whale intensity from empirical percentile ranks
+	// (volume-weighted mean and hour maximum; zero unless rank tracking
+	// is enabled)
+	WhaleRankVWMean float64
+	WhaleRankMax    float64
+
 	// Internal accumulators for VWAP/std calculation
 	sumPriceQty   float64
 	sumQty        float64
 	sumPriceSqQty float64
+
+	// Count of IDs recorded, for the continuity gap calculation
+	idCount  int64
+	sumFills int64
+
+	// Accumulator for the volume-weighted mean rank
+	sumRankQty float64
+
+	// Accumulators for the round-size and round-notional volume shares
+	roundSizeVol     float64
+	roundNotionalVol float64
+
+	// Volume-by-price histogram: coarse bins of profileWidth, keyed by
+	// rounded price/width
+	profile      map[int64]float64
+	profileWidth float64
+
+	// Per-minute volume buckets for the intra-hour shape descriptors
+	minuteVol [60]float64
+
+	// Optional compensated shadow accumulators and the observed naive-sum
+	// drift (see EnablePreciseSums)
+	precise  *preciseSums
+	sumDrift float64
 }
 
 // NewHourlyBar creates a new hourly bar
@@ -51,6 +162,22 @@ func NewHourlyBar() *HourlyBar {
 	return &HourlyBar{}
 }
 
+// EnablePreciseSums makes the bar accumulate its volume sums with Kahan
+// compensated summation alongside the naive sums. At Finalize the
+// compensated values become authoritative and the relative drift of the
+// naive sums is recorded (see SumDrift), quantifying the accumulation
+// error over hours with tens of millions of trades.
+func (h *HourlyBar) EnablePreciseSums() {
+	h.precise = &preciseSums{}
+}
+
+// SumDrift returns the largest relative difference observed between the
+// naive and compensated volume sums. Zero unless precise sums are enabled
+// and Finalize has run.
+func (h *HourlyBar) SumDrift() float64 {
+	return h.sumDrift
+}
+
 // AddTrade adds a trade to the hourly bar
 // isWhaleP99 and isWhaleP999 indicate whale classification
 func (h *HourlyBar) AddTrade(
@@ -61,6 +188,22 @@ func (h *HourlyBar) AddTrade(
 ) {
 	dollarVol := price * qty
 
+	// OHLC: trades arrive in time order within an hour, so the first call
+	// sets the open and every call moves the close
+	if h.NTrades == 0 {
+		h.OpenPrice = price
+		h.HighPrice = price
+		h.LowPrice = price
+	} else {
+		if price > h.HighPrice {
+			h.HighPrice = price
+		}
+		if price < h.LowPrice {
+			h.LowPrice = price
+		}
+	}
+	h.ClosePrice = price
+
 	// Basic volumes
 	if isBuy {
 		h.BuyVol += qty
@@ -101,21 +244,202 @@ func (h *HourlyBar) AddTrade(
 	} else {
 		h.VolLast30Min += qty
 	}
+	if minute >= 0 && minute < 60 {
+		h.minuteVol[minute] += qty
+	}
 
 	// Max trade size
 	if qty > h.MaxTradeSize {
 		h.MaxTradeSize = qty
 	}
 
+	// Volume-by-price histogram, binned at 10bp of the hour's first price
+	if h.profile == nil {
+		h.profile = make(map[int64]float64)
+		h.profileWidth = price * 0.001
+	}
+	if h.profileWidth > 0 {
+		h.profile[int64(math.Round(price/h.profileWidth))] += qty
+	}
+
+	// Round-number footprints
+	if isRoundSize(qty) {
+		h.roundSizeVol += qty
+	}
+	if isRoundNotional(dollarVol) {
+		h.roundNotionalVol += qty
+	}
+
 	// Accumulators for VWAP and price std
 	h.sumPriceQty += price * qty
 	h.sumQty += qty
 	h.sumPriceSqQty += price * price * qty
+
+	if h.precise != nil {
+		h.precise.add(price, qty, dollarVol, isBuy)
+	}
+}
+
+// isRoundSize reports whether a quantity sits on a round size grid
+// (multiples of 0.5 base units, covering 0.5, 1.0, 10, ...)
+func isRoundSize(qty float64) bool {
+	if qty < 0.5 {
+		return false
+	}
+	m := math.Mod(qty, 0.5)
+	return m < 1e-9 || 0.5-m < 1e-9
+}
+
+// isRoundNotional reports whether a trade's notional sits within 0.25% of
+// a $100k multiple, the clip size manual flow tends to be sent in
+func isRoundNotional(notional float64) bool {
+	const clip = 100_000.0
+	if notional < clip/2 {
+		return false
+	}
+	m := math.Mod(notional, clip)
+	return m < clip*0.0025 || clip-m < clip*0.0025
+}
+
+// ensureTiers lazily allocates the extra-tier maps
+func (h *HourlyBar) ensureTiers() {
+	if h.TierBuyVol == nil {
+		h.TierBuyVol = make(map[string]float64)
+		h.TierSellVol = make(map[string]float64)
+		h.TierBuyCount = make(map[string]int64)
+		h.TierSellCount = make(map[string]int64)
+	}
+}
+
+// AddTierTrade folds a trade that cleared an extra whale tier's threshold
+// into that tier's accumulators
+func (h *HourlyBar) AddTierTrade(label string, qty float64, isBuy bool) {
+	h.ensureTiers()
+	if isBuy {
+		h.TierBuyVol[label] += qty
+		h.TierBuyCount[label]++
+	} else {
+		h.TierSellVol[label] += qty
+		h.TierSellCount[label]++
+	}
+}
+
+// RecordRank tracks a trade's empirical percentile rank for the
+// volume-weighted mean and maximum rank columns
+func (h *HourlyBar) RecordRank(rank, qty float64) {
+	h.sumRankQty += rank * qty
+	if rank > h.WhaleRankMax {
+		h.WhaleRankMax = rank
+	}
+}
+
+// RecordIDs tracks the aggregate trade ID range seen within the hour and
+// how many individual fills the aggregate covered
+func (h *HourlyBar) RecordIDs(aggID, fills int64) {
+	if h.idCount == 0 || aggID < h.FirstAggID {
+		h.FirstAggID = aggID
+	}
+	if aggID > h.LastAggID {
+		h.LastAggID = aggID
+	}
+	h.idCount++
+	h.sumFills += fills
 }
 
 // Finalize calculates derived statistics (VWAP, PriceStd)
 // Must be called after all trades are added
 func (h *HourlyBar) Finalize() {
+	// Self-check the naive sums against the compensated ones, then make
+	// the compensated sums authoritative so the derived stats below use
+	// them too
+	if h.precise != nil {
+		for _, pair := range [][2]float64{
+			{h.BuyVol, h.precise.buyVol.sum},
+			{h.SellVol, h.precise.sellVol.sum},
+			{h.BuyVolUSD, h.precise.buyVolUSD.sum},
+			{h.SellVolUSD, h.precise.sellVolUSD.sum},
+			{h.sumPriceQty, h.precise.sumPriceQty.sum},
+			{h.sumQty, h.precise.sumQty.sum},
+			{h.sumPriceSqQty, h.precise.sumPriceSqQty.sum},
+		} {
+			if d := relDrift(pair[0], pair[1]); d > h.sumDrift {
+				h.sumDrift = d
+			}
+		}
+		h.BuyVol = h.precise.buyVol.sum
+		h.SellVol = h.precise.sellVol.sum
+		h.BuyVolUSD = h.precise.buyVolUSD.sum
+		h.SellVolUSD = h.precise.sellVolUSD.sum
+		h.sumPriceQty = h.precise.sumPriceQty.sum
+		h.sumQty = h.precise.sumQty.sum
+		h.sumPriceSqQty = h.precise.sumPriceSqQty.sum
+	}
+
+	if h.idCount > 0 {
+		missing := (h.LastAggID - h.FirstAggID + 1) - h.idCount
+		if missing < 0 {
+			missing = 0
+		}
+		h.MissingIDCount = missing
+		h.AvgFillsPerAggTrade = float64(h.sumFills) / float64(h.idCount)
+	}
+
+	if h.sumQty > 0 && h.sumRankQty > 0 {
+		h.WhaleRankVWMean = h.sumRankQty / h.sumQty
+	}
+
+	if h.sumQty > 0 {
+		h.RoundSizeVolRatio = h.roundSizeVol / h.sumQty
+		h.RoundNotionalVolRatio = h.roundNotionalVol / h.sumQty
+	}
+
+	if h.sumQty > 0 {
+		// Volume-weighted moments of the minute distribution
+		var mean float64
+		for m, vol := range h.minuteVol {
+			mean += float64(m) * vol
+		}
+		mean /= h.sumQty
+
+		var m2, m3 float64
+		for m, vol := range h.minuteVol {
+			d := float64(m) - mean
+			m2 += d * d * vol
+			m3 += d * d * d * vol
+		}
+		m2 /= h.sumQty
+		m3 /= h.sumQty
+		if m2 > 0 {
+			h.VolMinuteSkew = m3 / math.Pow(m2, 1.5)
+		}
+
+		// Busiest sliding 5-minute window
+		var window, peak float64
+		for m := 0; m < 5; m++ {
+			window += h.minuteVol[m]
+		}
+		peak = window
+		for m := 5; m < 60; m++ {
+			window += h.minuteVol[m] - h.minuteVol[m-5]
+			if window > peak {
+				peak = window
+			}
+		}
+		h.VolPeak5mRatio = peak / h.sumQty
+	}
+
+	if h.sumQty > 0 && len(h.profile) > 0 {
+		var pocBin int64
+		var pocVol float64
+		for bin, vol := range h.profile {
+			if vol > pocVol || (vol == pocVol && bin < pocBin) {
+				pocBin, pocVol = bin, vol
+			}
+		}
+		h.PocPrice = float64(pocBin) * h.profileWidth
+		h.VolConcentration = pocVol / h.sumQty
+	}
+
 	if h.sumQty > 0 {
 		h.VWAP = h.sumPriceQty / h.sumQty
 
@@ -130,6 +454,31 @@ func (h *HourlyBar) Finalize() {
 
 // Merge combines another hourly bar into this one
 func (h *HourlyBar) Merge(other *HourlyBar) {
+	// OHLC: extremes combine directly; open/close are ordered by aggregate
+	// trade ID when both sides carry IDs (same-venue bars), otherwise the
+	// receiver counts as the earlier bar
+	if other.NTrades > 0 {
+		if h.NTrades == 0 {
+			h.OpenPrice = other.OpenPrice
+			h.HighPrice = other.HighPrice
+			h.LowPrice = other.LowPrice
+			h.ClosePrice = other.ClosePrice
+		} else {
+			if other.HighPrice > h.HighPrice {
+				h.HighPrice = other.HighPrice
+			}
+			if other.LowPrice < h.LowPrice {
+				h.LowPrice = other.LowPrice
+			}
+			if other.FirstAggID != 0 && (h.FirstAggID == 0 || other.FirstAggID < h.FirstAggID) {
+				h.OpenPrice = other.OpenPrice
+			}
+			if other.LastAggID >= h.LastAggID {
+				h.ClosePrice = other.ClosePrice
+			}
+		}
+	}
+
 	h.BuyVol += other.BuyVol
 	h.SellVol += other.SellVol
 	h.NTrades += other.NTrades
@@ -146,6 +495,22 @@ func (h *HourlyBar) Merge(other *HourlyBar) {
 	h.WhaleBuyCountP999 += other.WhaleBuyCountP999
 	h.WhaleSellCountP999 += other.WhaleSellCountP999
 
+	if other.TierBuyVol != nil {
+		h.ensureTiers()
+		for label, v := range other.TierBuyVol {
+			h.TierBuyVol[label] += v
+		}
+		for label, v := range other.TierSellVol {
+			h.TierSellVol[label] += v
+		}
+		for label, n := range other.TierBuyCount {
+			h.TierBuyCount[label] += n
+		}
+		for label, n := range other.TierSellCount {
+			h.TierSellCount[label] += n
+		}
+	}
+
 	h.VolFirst30Min += other.VolFirst30Min
 	h.VolLast30Min += other.VolLast30Min
 
@@ -159,6 +524,63 @@ func (h *HourlyBar) Merge(other *HourlyBar) {
 	h.sumPriceQty += other.sumPriceQty
 	h.sumQty += other.sumQty
 	h.sumPriceSqQty += other.sumPriceSqQty
+
+	if h.precise != nil && other.precise != nil {
+		h.precise.buyVol.add(other.precise.buyVol.sum)
+		h.precise.sellVol.add(other.precise.sellVol.sum)
+		h.precise.buyVolUSD.add(other.precise.buyVolUSD.sum)
+		h.precise.sellVolUSD.add(other.precise.sellVolUSD.sum)
+		h.precise.sumPriceQty.add(other.precise.sumPriceQty.sum)
+		h.precise.sumQty.add(other.precise.sumQty.sum)
+		h.precise.sumPriceSqQty.add(other.precise.sumPriceSqQty.sum)
+	}
+	if other.sumDrift > h.sumDrift {
+		h.sumDrift = other.sumDrift
+	}
+
+	if other.SourceRest > h.SourceRest {
+		h.SourceRest = other.SourceRest
+	}
+	if other.Source > h.Source {
+		h.Source = other.Source
+	}
+
+	h.IcebergPrints += other.IcebergPrints
+	h.roundSizeVol += other.roundSizeVol
+	h.roundNotionalVol += other.roundNotionalVol
+
+	for m := range h.minuteVol {
+		h.minuteVol[m] += other.minuteVol[m]
+	}
+
+	// Merge the volume profile, rebinning into this bar's grid when the
+	// widths differ (bars from different venues or deserialized bars)
+	if other.profile != nil {
+		if h.profile == nil {
+			h.profile = make(map[int64]float64, len(other.profile))
+			h.profileWidth = other.profileWidth
+		}
+		for bin, vol := range other.profile {
+			price := float64(bin) * other.profileWidth
+			h.profile[int64(math.Round(price/h.profileWidth))] += vol
+		}
+	}
+
+	h.sumRankQty += other.sumRankQty
+	if other.WhaleRankMax > h.WhaleRankMax {
+		h.WhaleRankMax = other.WhaleRankMax
+	}
+
+	if other.idCount > 0 {
+		if h.idCount == 0 || other.FirstAggID < h.FirstAggID {
+			h.FirstAggID = other.FirstAggID
+		}
+		if other.LastAggID > h.LastAggID {
+			h.LastAggID = other.LastAggID
+		}
+		h.idCount += other.idCount
+		h.sumFills += other.sumFills
+	}
 }
 
 // TotalVolume returns buy + sell volume
@@ -174,5 +596,33 @@ func (h *HourlyBar) NetVolume() float64 {
 // Clone creates a deep copy of the hourly bar
 func (h *HourlyBar) Clone() *HourlyBar {
 	clone := *h
+	if h.profile != nil {
+		clone.profile = make(map[int64]float64, len(h.profile))
+		for bin, vol := range h.profile {
+			clone.profile[bin] = vol
+		}
+	}
+	if h.precise != nil {
+		precise := *h.precise
+		clone.precise = &precise
+	}
+	if h.TierBuyVol != nil {
+		clone.TierBuyVol = make(map[string]float64, len(h.TierBuyVol))
+		clone.TierSellVol = make(map[string]float64, len(h.TierSellVol))
+		clone.TierBuyCount = make(map[string]int64, len(h.TierBuyCount))
+		clone.TierSellCount = make(map[string]int64, len(h.TierSellCount))
+		for label, v := range h.TierBuyVol {
+			clone.TierBuyVol[label] = v
+		}
+		for label, v := range h.TierSellVol {
+			clone.TierSellVol[label] = v
+		}
+		for label, n := range h.TierBuyCount {
+			clone.TierBuyCount[label] = n
+		}
+		for label, n := range h.TierSellCount {
+			clone.TierSellCount[label] = n
+		}
+	}
 	return &clone
 }
@@ -0,0 +1,56 @@
+package aggregator
+
+import (
+	"time"
+
+	"github.com/clement/aggtrades/internal/parser"
+)
+
+// stitchMaxGap is the maximum time between consecutive aggTrades that can
+// belong to the same logical order. Binance emits one aggTrade per price
+// level swept, so a single large market order appears as a burst of
+// same-side aggregates within a few milliseconds.
+const stitchMaxGap = 100 * time.Millisecond
+
+// stitchQtys returns, for each trade, the total quantity of the logical
+// order it was stitched into, plus the number of multi-trade orders found.
+// Consecutive trades stitch when they share the side, arrive within
+// stitchMaxGap of the previous trade, and the price moves with the side
+// (buys sweep the book upward, sells downward), i.e. the signature of one
+// order split across aggregates.
+func stitchQtys(trades []parser.Trade) ([]float64, int64) {
+	qtys := make([]float64, len(trades))
+	var orders int64
+
+	for i := 0; i < len(trades); {
+		j := i + 1
+		total := trades[i].Qty
+		for j < len(trades) {
+			prev, cur := trades[j-1], trades[j]
+			if cur.IsBuy() != prev.IsBuy() {
+				break
+			}
+			if cur.Time.Sub(prev.Time) > stitchMaxGap {
+				break
+			}
+			if prev.IsBuy() && cur.Price < prev.Price {
+				break
+			}
+			if !prev.IsBuy() && cur.Price > prev.Price {
+				break
+			}
+			total += cur.Qty
+			j++
+		}
+
+		for k := i; k < j; k++ {
+			qtys[k] = total
+		}
+		if j-i > 1 {
+			orders++
+		}
+		i = j
+	}
+
+	return qtys, orders
+}
@@ -0,0 +1,92 @@
+package aggregator
+
+import (
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/parser"
+)
+
+// TradeRecord holds the details of a single large trade
+type TradeRecord struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+	Qty   float64   `json:"qty"`
+	IsBuy bool      `json:"is_buy"`
+}
+
+// HourTopTrades holds the top-K largest trades for one hour
+type HourTopTrades struct {
+	Hour   time.Time     `json:"hour"`
+	Trades []TradeRecord `json:"trades"`
+}
+
+// EnableTopTrades turns on per-hour top-K largest trade tracking.
+// Must be called before processing starts.
+func (a *Aggregator) EnableTopTrades(k int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.topK = k
+	a.topTrades = make(map[time.Time][]TradeRecord)
+}
+
+// recordTopTrade inserts a trade into the hour's top-K list if it qualifies.
+// The list is kept sorted ascending by qty; callers hold a.mu.
+func (a *Aggregator) recordTopTrade(hour time.Time, trade parser.Trade) {
+	if a.topK <= 0 {
+		return
+	}
+
+	list := a.topTrades[hour]
+	if len(list) >= a.topK && trade.Qty <= list[0].Qty {
+		return
+	}
+
+	record := TradeRecord{
+		Time:  trade.Time,
+		Price: trade.Price,
+		Qty:   trade.Qty,
+		IsBuy: trade.IsBuy(),
+	}
+
+	idx := sort.Search(len(list), func(i int) bool {
+		return list[i].Qty >= trade.Qty
+	})
+
+	if len(list) < a.topK {
+		list = append(list, TradeRecord{})
+		copy(list[idx+1:], list[idx:])
+		list[idx] = record
+	} else {
+		// Evict the smallest (index 0) and shift down
+		copy(list[:idx-1], list[1:idx])
+		list[idx-1] = record
+	}
+	a.topTrades[hour] = list
+}
+
+// GetTopTradesForMonth returns and removes the top trades for a month's
+// hours, sorted by hour with trades in descending size order.
+func (a *Aggregator) GetTopTradesForMonth(month string) []HourTopTrades {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var result []HourTopTrades
+	for hour, list := range a.topTrades {
+		if hour.Format("2006-01") != month {
+			continue
+		}
+		// Reverse to descending size for output
+		trades := make([]TradeRecord, len(list))
+		for i, t := range list {
+			trades[len(list)-1-i] = t
+		}
+		result = append(result, HourTopTrades{Hour: hour, Trades: trades})
+		delete(a.topTrades, hour)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Hour.Before(result[j].Hour)
+	})
+	return result
+}
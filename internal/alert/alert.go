@@ -0,0 +1,212 @@
+// Package alert evaluates simple threshold rules against finalized hourly
+// bars and emits matches to a JSONL file and/or a webhook. It is meant for
+// re-processing recent data to locate notable events, not for low-latency
+// alerting.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// webhookTimeout bounds how long one webhook post may block processing
+const webhookTimeout = 5 * time.Second
+
+// Rule is one compiled threshold rule: <column> <op> <threshold>, where
+// column is any output column name and op is one of > >= < <= == !=
+type Rule struct {
+	expr      string
+	column    aggregator.Column
+	op        string
+	threshold float64
+}
+
+// ParseRule compiles a rule expression like "whale_buy_vol_p999 > 500"
+func ParseRule(expr string) (*Rule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("parse alert %q: want '<column> <op> <threshold>'", expr)
+	}
+
+	col, ok := aggregator.ColumnByName(fields[0])
+	if !ok {
+		return nil, fmt.Errorf("parse alert %q: unknown column %q", expr, fields[0])
+	}
+
+	switch fields[1] {
+	case ">", ">=", "<", "<=", "==", "!=":
+	default:
+		return nil, fmt.Errorf("parse alert %q: unknown operator %q", expr, fields[1])
+	}
+
+	threshold, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse alert %q: bad threshold %q", expr, fields[2])
+	}
+
+	return &Rule{expr: expr, column: col, op: fields[1], threshold: threshold}, nil
+}
+
+// Match returns whether the bar triggers the rule, and the column value
+func (r *Rule) Match(b *aggregator.HourlyBar) (bool, float64) {
+	var v float64
+	switch x := r.column.Value(b).(type) {
+	case int64:
+		v = float64(x)
+	case float64:
+		v = x
+	}
+
+	switch r.op {
+	case ">":
+		return v > r.threshold, v
+	case ">=":
+		return v >= r.threshold, v
+	case "<":
+		return v < r.threshold, v
+	case "<=":
+		return v <= r.threshold, v
+	case "==":
+		return v == r.threshold, v
+	default: // "!="
+		return v != r.threshold, v
+	}
+}
+
+// String returns the original expression
+func (r *Rule) String() string {
+	return r.expr
+}
+
+// Match is one triggered rule, as written to the alerts file and webhook
+type Match struct {
+	Time  time.Time `json:"time"`
+	Rule  string    `json:"rule"`
+	Value float64   `json:"value"`
+}
+
+// Engine evaluates a set of rules against bars and fans matches out to the
+// configured sinks. Safe for use from a single goroutine per Evaluate call.
+type Engine struct {
+	rules   []*Rule
+	webhook string
+
+	mu      sync.Mutex
+	file    *os.File
+	matches int
+	errs    int
+}
+
+// NewEngine compiles the rule expressions and opens the sinks. filePath
+// receives one JSON match per line (appended); webhook, if set, receives
+// each match as a JSON POST.
+func NewEngine(exprs []string, filePath, webhook string) (*Engine, error) {
+	e := &Engine{webhook: webhook}
+
+	for _, expr := range exprs {
+		rule, err := ParseRule(expr)
+		if err != nil {
+			return nil, err
+		}
+		e.rules = append(e.rules, rule)
+	}
+
+	if filePath != "" {
+		f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("open alert file: %w", err)
+		}
+		e.file = f
+	}
+
+	return e, nil
+}
+
+// Evaluate runs all rules against one finalized bar, emitting any matches
+func (e *Engine) Evaluate(hour time.Time, bar *aggregator.HourlyBar) {
+	for _, rule := range e.rules {
+		hit, v := rule.Match(bar)
+		if !hit {
+			continue
+		}
+		e.emit(Match{Time: hour, Rule: rule.String(), Value: v})
+	}
+}
+
+// emit writes a match to the file and webhook; sink failures are counted,
+// not fatal — alerting must never abort a run
+func (e *Engine) emit(m Match) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.matches++
+
+	line, err := json.Marshal(m)
+	if err != nil {
+		e.errs++
+		return
+	}
+
+	if e.file != nil {
+		if _, err := e.file.Write(append(line, '\n')); err != nil {
+			e.errs++
+		}
+	}
+
+	if e.webhook != "" {
+		if err := e.post(line); err != nil {
+			e.errs++
+		}
+	}
+}
+
+// post sends one match to the webhook
+func (e *Engine) post(body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Stats returns how many matches were emitted and how many sink writes failed
+func (e *Engine) Stats() (matches, errors int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.matches, e.errs
+}
+
+// Close flushes and closes the alerts file
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	e.file = nil
+	return err
+}
@@ -14,18 +14,25 @@ const (
 	// S3 bucket endpoint for Binance Data Vision
 	s3BucketURL = "https://s3-ap-northeast-1.amazonaws.com/data.binance.vision"
 
+	// CDN endpoint used for HEAD probing when the listing API is blocked
+	cdnBaseURL = "https://data.binance.vision/data/spot/monthly/aggTrades"
+
 	// Maximum keys to request (200 is enough for monthly data spanning 10+ years)
 	maxKeys = 200
 
 	// HTTP timeout for S3 API requests
 	discoveryTimeout = 30 * time.Second
+
+	// Earliest month Binance spot data could exist
+	probeFloorMonth = "2017-01"
 )
 
 // DateRange represents the available date range for a symbol
 type DateRange struct {
-	FirstMonth string   // e.g., "2017-08"
-	LastMonth  string   // e.g., "2025-11"
-	AllMonths  []string // sorted list of all available months
+	FirstMonth string           // e.g., "2017-08"
+	LastMonth  string           // e.g., "2025-11"
+	AllMonths  []string         // sorted list of all available months
+	MonthSizes map[string]int64 // ZIP size in bytes per month (from the listing)
 }
 
 // S3 XML response structures
@@ -40,9 +47,25 @@ type s3Object struct {
 	Size int64  `xml:"Size"`
 }
 
-// DiscoverDateRange queries Binance Data Vision S3 bucket to find
-// all available aggTrades months for a given symbol.
+// DiscoverDateRange finds the available aggTrades months for a symbol.
+// Tries the S3 listing API first; if that is blocked or failing, falls
+// back to HEAD-probing the CDN directly.
 func DiscoverDateRange(ctx context.Context, symbol string) (*DateRange, error) {
+	dateRange, listErr := discoverViaListing(ctx, symbol)
+	if listErr == nil {
+		return dateRange, nil
+	}
+
+	dateRange, probeErr := discoverViaProbing(ctx, symbol)
+	if probeErr == nil {
+		return dateRange, nil
+	}
+
+	return nil, fmt.Errorf("listing failed (%v); probing failed: %w", listErr, probeErr)
+}
+
+// discoverViaListing queries the Binance Data Vision S3 bucket listing.
+func discoverViaListing(ctx context.Context, symbol string) (*DateRange, error) {
 	prefix := fmt.Sprintf("data/spot/monthly/aggTrades/%s/", symbol)
 
 	// Build S3 ListObjectsV2 URL
@@ -70,8 +93,8 @@ func DiscoverDateRange(ctx context.Context, symbol string) (*DateRange, error) {
 		return nil, fmt.Errorf("parse XML: %w", err)
 	}
 
-	// Extract months from file keys
-	months := extractMonths(result.Contents, symbol)
+	// Extract months and file sizes from the listing
+	months, sizes := extractMonths(result.Contents, symbol)
 	if len(months) == 0 {
 		return nil, fmt.Errorf("no aggTrades data found for symbol %s", symbol)
 	}
@@ -83,17 +106,93 @@ func DiscoverDateRange(ctx context.Context, symbol string) (*DateRange, error) {
 		FirstMonth: months[0],
 		LastMonth:  months[len(months)-1],
 		AllMonths:  months,
+		MonthSizes: sizes,
+	}, nil
+}
+
+// discoverViaProbing finds the date range by HEAD-probing monthly files on
+// the CDN. Used when the listing API is blocked but the CDN is reachable.
+// Assumes the published months form a contiguous range.
+func discoverViaProbing(ctx context.Context, symbol string) (*DateRange, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	// Find the latest available month: monthly files lag current time,
+	// so walk back a few months from now
+	lastMonth := ""
+	current := time.Now().UTC()
+	for i := 0; i < 4; i++ {
+		month := current.AddDate(0, -i, 0).Format("2006-01")
+		if monthExists(ctx, client, symbol, month) {
+			lastMonth = month
+			break
+		}
+	}
+	if lastMonth == "" {
+		return nil, fmt.Errorf("no recent months found for %s", symbol)
+	}
+
+	// Binary search for the first available month in [floor, lastMonth]
+	floor, _ := time.Parse("2006-01", probeFloorMonth)
+	last, _ := time.Parse("2006-01", lastMonth)
+	lo, hi := 0, monthsBetween(floor, last)
+
+	for lo < hi {
+		mid := (lo + hi) / 2
+		month := floor.AddDate(0, mid, 0).Format("2006-01")
+		if monthExists(ctx, client, symbol, month) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	firstMonth := floor.AddDate(0, lo, 0).Format("2006-01")
+
+	// Build the full month list
+	var months []string
+	first, _ := time.Parse("2006-01", firstMonth)
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		months = append(months, m.Format("2006-01"))
+	}
+
+	return &DateRange{
+		FirstMonth: firstMonth,
+		LastMonth:  lastMonth,
+		AllMonths:  months,
 	}, nil
 }
 
-// extractMonths parses S3 keys to extract YYYY-MM dates
+// monthExists checks via HEAD request whether a monthly file is published
+func monthExists(ctx context.Context, client *http.Client, symbol, month string) bool {
+	url := fmt.Sprintf("%s/%s/%s-aggTrades-%s.zip", cdnBaseURL, symbol, symbol, month)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// monthsBetween returns the number of whole months from a to b
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
+
+// extractMonths parses S3 keys to extract YYYY-MM dates and ZIP sizes
 // Keys look like: data/spot/monthly/aggTrades/BTCUSDT/BTCUSDT-aggTrades-2017-08.zip
-func extractMonths(objects []s3Object, symbol string) []string {
+func extractMonths(objects []s3Object, symbol string) ([]string, map[string]int64) {
 	// Pattern: {SYMBOL}-aggTrades-{YYYY-MM}.zip
 	pattern := regexp.MustCompile(
 		fmt.Sprintf(`%s-aggTrades-(\d{4}-\d{2})\.zip$`, regexp.QuoteMeta(symbol)))
 
 	months := make([]string, 0, len(objects)/2) // /2 because of .CHECKSUM files
+	sizes := make(map[string]int64, len(objects)/2)
 	seen := make(map[string]bool)
 
 	for _, obj := range objects {
@@ -104,8 +203,9 @@ func extractMonths(objects []s3Object, symbol string) []string {
 				months = append(months, month)
 				seen[month] = true
 			}
+			sizes[month] = obj.Size
 		}
 	}
 
-	return months
+	return months, sizes
 }
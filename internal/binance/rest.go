@@ -0,0 +1,256 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/clement/aggtrades/internal/parser"
+)
+
+const (
+	// REST endpoint for recent aggTrades; used to top up the tail of a
+	// batch run, since the daily dump files lag real time by about a day
+	restAggTradesURL = "https://api.binance.com/api/v3/aggTrades"
+
+	// Maximum trades per REST page (the API's hard limit)
+	restPageLimit = 1000
+
+	// HTTP timeout per REST page
+	restTimeout = 30 * time.Second
+
+	// Pause between pages to stay well inside the API rate limits
+	restPageDelay = 100 * time.Millisecond
+
+	// Attempts per page before giving up on rate-limit responses
+	restMaxAttempts = 5
+
+	// Per-minute request weight budget; when the used weight reported by
+	// the API passes restWeightSoft of it, pacing waits for the window to
+	// reset instead of risking a 429 (or worse, a 418 IP ban)
+	restWeightLimit = 1200
+	restWeightSoft  = 0.8
+)
+
+// restAggTrade is the REST wire format; prices and quantities arrive as
+// strings
+type restAggTrade struct {
+	AggID        int64  `json:"a"`
+	Price        string `json:"p"`
+	Qty          string `json:"q"`
+	FirstID      int64  `json:"f"`
+	LastID       int64  `json:"l"`
+	Timestamp    int64  `json:"T"`
+	IsBuyerMaker bool   `json:"m"`
+}
+
+// restClient wraps an HTTP client with Binance rate-limit awareness: it
+// honors Retry-After on 429 (rate limited) and 418 (banned for ignoring
+// 429s), and it watches the X-MBX-USED-WEIGHT-1M header to pause before
+// the budget runs out, so bulk backfills cannot get the IP banned.
+type restClient struct {
+	http *http.Client
+
+	// Do not send the next request before this time
+	cooldownUntil time.Time
+}
+
+func newRESTClient() *restClient {
+	return &restClient{http: &http.Client{Timeout: restTimeout}}
+}
+
+// do sends a GET, retrying rate-limit responses with the server's
+// Retry-After (exponential fallback when absent) and recording any
+// cooldown the response headers call for
+func (c *restClient) do(ctx context.Context, url string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if wait := time.Until(c.cooldownUntil); wait > 0 {
+			if err := sleepCtx(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+			wait := retryAfter(resp, attempt)
+			resp.Body.Close()
+			if attempt+1 >= restMaxAttempts {
+				return nil, fmt.Errorf("REST: HTTP %d, giving up after %d attempts", resp.StatusCode, restMaxAttempts)
+			}
+			if err := sleepCtx(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Proactive pacing: when the used weight nears the per-minute
+		// budget, hold the next request until the window resets
+		if used, err := strconv.Atoi(resp.Header.Get("X-Mbx-Used-Weight-1m")); err == nil {
+			if float64(used) >= restWeightLimit*restWeightSoft {
+				now := time.Now()
+				c.cooldownUntil = now.Truncate(time.Minute).Add(time.Minute)
+			}
+		}
+
+		return resp, nil
+	}
+}
+
+// retryAfter reads the server's Retry-After header, falling back to an
+// exponential delay when it is missing or unparseable
+func retryAfter(resp *http.Response, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	wait := time.Duration(1<<attempt) * time.Second
+	if wait > time.Minute {
+		wait = time.Minute
+	}
+	return wait
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// FetchAggTrades pages through the REST aggTrades endpoint and returns all
+// trades for symbol in [start, end), in time order. The first page locates
+// the starting aggregate ID by time; every following page continues with
+// fromId, which cannot skip or repeat trades even when more than a full
+// page shares a single timestamp (a real failure mode of time-cursor
+// paging during busy minutes).
+func FetchAggTrades(ctx context.Context, symbol string, start, end time.Time) ([]parser.Trade, error) {
+	client := newRESTClient()
+
+	var trades []parser.Trade
+	endMs := end.UnixMilli()
+
+	// The API caps startTime/endTime windows at one hour, so the locator
+	// page uses a clamped window; paging past it is fromId's job
+	windowEnd := endMs
+	if hourEnd := start.Add(time.Hour).UnixMilli(); hourEnd < windowEnd {
+		windowEnd = hourEnd
+	}
+	page, err := fetchAggTradesByTime(ctx, client, symbol, start.UnixMilli(), windowEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := false
+	for len(page) > 0 {
+		for _, raw := range page {
+			trade, err := raw.toTrade()
+			if err != nil {
+				return nil, err
+			}
+			if trade.Time.UnixMilli() >= endMs {
+				return trades, nil
+			}
+			trades = append(trades, trade)
+		}
+
+		// A short fromId page means we caught up to the newest trade; a
+		// short locator page just means a quiet first hour
+		if byID && len(page) < restPageLimit {
+			break
+		}
+		fromID := page[len(page)-1].AggID + 1
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(restPageDelay):
+		}
+
+		page, err = fetchAggTradesByID(ctx, client, symbol, fromID)
+		if err != nil {
+			return nil, err
+		}
+		byID = true
+	}
+
+	return trades, nil
+}
+
+// fetchAggTradesByTime fetches the page of trades starting at startMs, used
+// once to locate the first aggregate ID of the range
+func fetchAggTradesByTime(ctx context.Context, client *restClient, symbol string, startMs, endMs int64) ([]restAggTrade, error) {
+	url := fmt.Sprintf("%s?symbol=%s&startTime=%d&endTime=%d&limit=%d",
+		restAggTradesURL, symbol, startMs, endMs, restPageLimit)
+
+	resp, err := client.do(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("REST aggTrades: HTTP %d", resp.StatusCode)
+	}
+
+	var page []restAggTrade
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("REST aggTrades: decode: %w", err)
+	}
+	return page, nil
+}
+
+// fetchAggTradesByID fetches the page of trades starting at aggregate ID
+// fromID (fromId cannot be combined with time parameters, so callers filter
+// the end of the range themselves)
+func fetchAggTradesByID(ctx context.Context, client *restClient, symbol string, fromID int64) ([]restAggTrade, error) {
+	url := fmt.Sprintf("%s?symbol=%s&fromId=%d&limit=%d",
+		restAggTradesURL, symbol, fromID, restPageLimit)
+
+	resp, err := client.do(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("REST aggTrades: HTTP %d", resp.StatusCode)
+	}
+
+	var page []restAggTrade
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("REST aggTrades: decode: %w", err)
+	}
+	return page, nil
+}
+
+func (r restAggTrade) toTrade() (parser.Trade, error) {
+	price, err := strconv.ParseFloat(r.Price, 64)
+	if err != nil {
+		return parser.Trade{}, fmt.Errorf("REST aggTrades: bad price %q: %w", r.Price, err)
+	}
+	qty, err := strconv.ParseFloat(r.Qty, 64)
+	if err != nil {
+		return parser.Trade{}, fmt.Errorf("REST aggTrades: bad qty %q: %w", r.Qty, err)
+	}
+	return parser.Trade{
+		Time:         time.UnixMilli(r.Timestamp).UTC(),
+		Price:        price,
+		Qty:          qty,
+		AggID:        r.AggID,
+		FirstID:      r.FirstID,
+		LastID:       r.LastID,
+		IsBuyerMaker: r.IsBuyerMaker,
+	}, nil
+}
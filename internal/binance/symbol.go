@@ -0,0 +1,42 @@
+package binance
+
+import "strings"
+
+// knownQuoteAssets are Binance spot quote assets, longest-suffix first so
+// e.g. BTCUSDT resolves to USDT rather than USD-like guesses
+var knownQuoteAssets = []string{
+	"FDUSD", "USDT", "BUSD", "USDC", "TUSD", "USDP", "DAI",
+	"BTC", "ETH", "BNB", "EUR", "GBP", "TRY", "BRL", "JPY", "AUD",
+	"RUB", "UAH", "ZAR", "NGN", "PLN", "ARS", "IDRT", "BIDR", "VAI",
+	"DOGE", "XRP", "TRX",
+}
+
+// dollarQuoteAssets are quote assets pegged to the US dollar, for which
+// the *_usd output columns are actually denominated in dollars
+var dollarQuoteAssets = map[string]bool{
+	"USDT": true, "BUSD": true, "USDC": true,
+	"TUSD": true, "FDUSD": true, "USDP": true, "DAI": true,
+}
+
+// DollarQuoteAssets returns the known US dollar stablecoin quote assets,
+// most liquid first. Used to find the quote variants of a base asset.
+func DollarQuoteAssets() []string {
+	return []string{"USDT", "BUSD", "USDC", "FDUSD", "TUSD", "USDP", "DAI"}
+}
+
+// QuoteAsset detects the quote asset from a trading pair symbol.
+// Returns empty string if the quote cannot be determined.
+func QuoteAsset(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	for _, quote := range knownQuoteAssets {
+		if strings.HasSuffix(symbol, quote) && len(symbol) > len(quote) {
+			return quote
+		}
+	}
+	return ""
+}
+
+// IsDollarQuote returns true if the quote asset is a US dollar stablecoin
+func IsDollarQuote(quote string) bool {
+	return dollarQuoteAssets[strings.ToUpper(quote)]
+}
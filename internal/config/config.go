@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/clement/aggtrades/internal/binance"
 	"github.com/shirou/gopsutil/v3/mem"
 )
 
@@ -13,10 +16,15 @@ const (
 	// Binance Data Vision base URL
 	BaseURL = "https://data.binance.vision/data/spot/monthly/aggTrades"
 
+	// Daily Data Vision files, used to assemble months whose monthly
+	// archive is not yet published (the current, incomplete month)
+	DailyBaseURL = "https://data.binance.vision/data/spot/daily/aggTrades"
+
 	// Default values
 	DefaultSymbol          = "BTCUSDT"
 	DefaultMaxMemoryPct    = 80
 	DefaultDownloadWorkers = 3
+	DefaultPrefetch        = 4
 
 	// Processing constants
 	PercentileWindowDays  = 7
@@ -40,6 +48,252 @@ type Config struct {
 	MaxMemoryBytes int64
 	DownloadWorkers int
 	NoTUI        bool
+
+	// Prefetch bounds how many downloaded-but-unprocessed months may accumulate
+	Prefetch int
+
+	// LockFile pins archive checksums: when the file exists every
+	// downloaded month must match its pinned sha256, and when it does not
+	// a successful run creates it from the observed checksums
+	LockFile string
+
+	// Offline aggregates only from ZIPs/CSVs already in the temp directory
+	// and fails on months missing locally instead of downloading them
+	Offline bool
+
+	// ParseBackend selects how CSVs are read: "bufio" (default) streams
+	// through a read buffer, "mmap" maps the file and parses in place,
+	// which can be faster on fast local storage
+	ParseBackend string
+
+	// ParseBatchSize overrides how many trades the parser accumulates
+	// before handing a batch to the aggregator (0 uses the built-in
+	// default). Larger batches amortize per-batch overhead; smaller
+	// ones bound peak memory.
+	ParseBatchSize int
+
+	// WhalePercentiles adds whale column groups for extra percentile
+	// tiers beyond the built-in P99/P99.9 (comma-separated, e.g.
+	// "95,99.5"). Parsed into WhaleTiers by Validate.
+	WhalePercentiles string
+
+	// WhaleTiers is the parsed form of WhalePercentiles
+	WhaleTiers []float64
+
+	// WhaleNotional computes whale thresholds over USD notional
+	// (price*qty) instead of raw quantity. Quantity thresholds drift
+	// badly as price moves 10x over multi-year builds; notional tiers
+	// stay comparable across periods. Must be chosen before the first
+	// run of a build — resuming with the other mode would mix units in
+	// the checkpointed reservoirs.
+	WhaleNotional bool
+
+	// CompareWindowDays, when positive, runs a second whale detector
+	// with this rolling window (days) in parallel over the same trade
+	// stream and emits its P99/P99.9 column groups suffixed _w<days>,
+	// so window sensitivity can be studied from a single pass.
+	CompareWindowDays int
+
+	// LatePolicy decides what happens to trades arriving for an hour
+	// whose bar has already finalized: "drop" (default) discards and
+	// counts them, keeping published bars immutable, while "revise"
+	// folds them into the bar and re-publishes it as a correction event.
+	LatePolicy string
+
+	// LateGraceHours holds bars open until they have fallen this many
+	// hours behind the newest trade seen, giving late data time to
+	// arrive before finalization. Shares the early-finalization cutoff
+	// of FlushAfterHours; setting both is an error.
+	LateGraceHours int
+
+	// FlushAfterHours, when positive, finalizes hourly bars that have
+	// fallen this many hours behind the trade stream during processing,
+	// bounding pending-bar growth on very long files. Trades are
+	// chronological within a file, so a bar that far behind receives no
+	// more trades.
+	FlushAfterHours int
+
+	// ProcessWorkers bounds how many symbols of a multi-symbol run are
+	// processed concurrently. Each symbol stays on a single worker, so
+	// within-symbol chronology is preserved; the memory budget is split
+	// between the active workers.
+	ProcessWorkers int
+
+	// Rollups emits daily and monthly summary companion files
+	Rollups bool
+
+	// GoMemLimit overrides the GOMEMLIMIT derived from MaxMemoryBytes
+	// (a size like "8GB", or "off" to leave the runtime default)
+	GoMemLimit string
+
+	// GoGC overrides the GOGC percentage (0 leaves the runtime default)
+	GoGC int
+
+	// TelemetryEndpoint, when set, receives anonymous run summaries
+	// (durations, throughput, error classes; never data content)
+	TelemetryEndpoint string
+
+	// KeepBackup preserves the previous output file as <output>.bak when
+	// overwriting
+	KeepBackup bool
+
+	// LPMeasurement is the line-protocol measurement name for --format lp
+	LPMeasurement string
+
+	// LPTags holds extra line-protocol tags as "key=value,key=value"
+	// (the symbol tag is always included)
+	LPTags string
+
+	// PublishURL, when set, streams each finalized bar to a NATS server
+	// (e.g. nats://localhost:4222)
+	PublishURL string
+
+	// PublishSubject is the NATS subject bar events are published to
+	PublishSubject string
+
+	// Alerts holds threshold rules (e.g. "whale_buy_vol_p999 > 500")
+	// evaluated against each bar as it finalizes
+	Alerts []string
+
+	// AlertFile receives one JSON line per alert match
+	// (default "alerts.jsonl" when rules are set)
+	AlertFile string
+
+	// AlertWebhook, when set, receives each alert match as a JSON POST
+	AlertWebhook string
+
+	// PruneCheckpoint deletes the per-month bar JSONs after a successful
+	// final write (detector state is kept for future extension runs)
+	PruneCheckpoint bool
+
+	// ProgressFile continuously receives a small JSON progress snapshot
+	// that external scripts can poll (empty = disabled)
+	ProgressFile string
+
+	// EncryptKeyFile holds a 256-bit key; when set, the output file is
+	// sealed with AES-256-GCM so the dataset is never stored unencrypted
+	EncryptKeyFile string
+
+	// CountFill selects how count columns (n_trades, whale counts) are
+	// filled in interpolated gaps: "interpolate" (default) or "zero"
+	CountFill string
+
+	// WhaleRanks tracks each trade's empirical percentile rank against the
+	// rolling window, populating the continuous whale-intensity columns
+	WhaleRanks bool
+
+	// StitchOrders stitches bursts of same-side aggTrades (one order split
+	// across price levels) back into logical orders before whale
+	// classification
+	StitchOrders bool
+
+	// PreciseSums accumulates per-hour volume sums with Kahan compensated
+	// summation and reports how far the naive float sums drifted
+	PreciseSums bool
+
+	// RestTopUp extends the output past the dump files with the most
+	// recent complete hours fetched from the REST aggTrades endpoint,
+	// flagged in the source_rest column
+	RestTopUp bool
+
+	// AuthHeaders injects headers into downloads from matching base URLs
+	// ("urlprefix=Header: value"), for authenticated internal mirrors of
+	// the archives; the public endpoints stay unauthenticated
+	AuthHeaders []string
+
+	// IPVersion forces downloads onto one IP family ("4" or "6"; empty or
+	// "auto" lets the dialer pick), a workaround for broken IPv6 routes
+	// to the CDN
+	IPVersion string
+
+	// DNSServer overrides the system resolver for downloads (host or
+	// host:port)
+	DNSServer string
+
+	// DNSCacheTTL caches download DNS answers for this long (0 = no cache)
+	DNSCacheTTL time.Duration
+
+	// UserAgent overrides the User-Agent sent with downloads (default
+	// "aggtrades/<version>"); every request also carries a random
+	// X-Request-Id for traceability
+	UserAgent string
+
+	// Simulate runs the whole pipeline on generated synthetic months
+	// instead of downloading, to exercise checkpoint/resume and memory
+	// behavior without network access. Requires explicit --start/--end.
+	Simulate bool
+
+	// Lookback anchors the range to the latest available month ("36m" =
+	// the last 36 months) and automatically includes, then trims, the
+	// extra history needed to warm the whale window. Mutually exclusive
+	// with --start/--end.
+	Lookback string
+
+	// LookbackStart is the first hour kept in a lookback run; earlier
+	// bars only warmed the whale window and are trimmed (derived, not a
+	// flag)
+	LookbackStart time.Time
+
+	// Format selects the output format: parquet (default), csv, or jsonl.
+	// Empty means detect from the output file extension.
+	Format string
+
+	// Columns optionally restricts which output columns are written
+	// (comma-separated; empty means all)
+	Columns string
+
+	// Transforms declares extra derived columns computed at write time
+	// (comma-separated kind:column specs, e.g. "log1p:buy_vol,zscore:n_trades:720")
+	Transforms string
+
+	// Filter is an optional per-trade filter expression (e.g. "qty >= 0.001")
+	Filter string
+
+	// TopKTrades tracks the K largest trades per hour (0 = disabled)
+	TopKTrades int
+
+	// Day-precision range bounds (zero = month-aligned). The covering
+	// months are downloaded but only trades within the range aggregate.
+	RangeStart time.Time
+	RangeEnd   time.Time // exclusive
+
+	// Hour exclusion windows: trades inside these are dropped from
+	// aggregation (known bad data periods, exchange incidents, ...)
+	ExcludeHours   string // inline comma-separated start/end windows
+	ExcludeFile    string // file with one window per line
+	ExcludeWindows []TimeWindow
+
+	// MonthSizes holds expected ZIP sizes per month from the discovery
+	// listing (may be empty when --start/--end were given explicitly).
+	// Used to validate downloads and weight progress/ETA by bytes.
+	MonthSizes map[string]int64
+
+	// QuoteAsset is detected from the symbol (e.g. USDT for BTCUSDT).
+	// The *_usd output columns are only dollar-denominated when this is
+	// a dollar stablecoin.
+	QuoteAsset    string
+	QuoteIsDollar bool
+
+	// Wide also writes a wide-format matrix aligned on a shared hourly
+	// index when multiple symbols are given
+	Wide bool
+
+	// Consolidate merges all dollar-stable quote variants of the base
+	// asset into one consolidated flow series
+	Consolidate bool
+
+	// DelistedAfter records the last published month when discovery shows
+	// the symbol stopped publishing before the requested end date
+	DelistedAfter string
+
+	// MaxTempSize bounds the temp directory size (e.g. "100GB", empty = unlimited)
+	MaxTempSize      string
+	MaxTempSizeBytes int64
+
+	// MaxTotalDownload caps the bytes fetched over the network in one run
+	// (e.g. "50GB", empty = unlimited), a safety net for metered connections
+	MaxTotalDownload      string
+	MaxTotalDownloadBytes int64
 }
 
 // New creates a new Config with default values
@@ -53,6 +307,8 @@ func New() *Config {
 		EndDate:         "", // resolved dynamically
 		MaxMemoryPct:    DefaultMaxMemoryPct,
 		DownloadWorkers: DefaultDownloadWorkers,
+		Prefetch:        DefaultPrefetch,
+		ProcessWorkers:  1,
 		NoTUI:           false,
 	}
 }
@@ -67,6 +323,20 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("end date not set (use --end or let auto-discovery resolve it)")
 	}
 
+	// Accept day precision (YYYY-MM-DD): the covering months are downloaded
+	// and trades outside the requested days are dropped during aggregation
+	if t, err := time.Parse("2006-01-02", c.StartDate); err == nil {
+		c.RangeStart = t.UTC()
+		c.StartDate = t.Format("2006-01")
+	}
+	if t, err := time.Parse("2006-01-02", c.EndDate); err == nil {
+		c.RangeEnd = t.UTC().AddDate(0, 0, 1) // end day is inclusive
+		c.EndDate = t.Format("2006-01")
+	}
+	if !c.RangeStart.IsZero() && !c.RangeEnd.IsZero() && !c.RangeStart.Before(c.RangeEnd) {
+		return fmt.Errorf("start date %s is after end date", c.RangeStart.Format("2006-01-02"))
+	}
+
 	// Validate date formats
 	start, err := time.Parse("2006-01", c.StartDate)
 	if err != nil {
@@ -92,6 +362,86 @@ func (c *Config) Validate() error {
 		c.OutputFile = fmt.Sprintf("aggtrades_%s_%s_%s.parquet", c.Symbol, c.StartDate, c.EndDate)
 	}
 
+	// Parse hour exclusion windows
+	if err := c.parseExcludeWindows(); err != nil {
+		return err
+	}
+
+	// Detect quote asset so consumers know what the *_usd columns mean
+	c.QuoteAsset = binance.QuoteAsset(c.Symbol)
+	c.QuoteIsDollar = binance.IsDollarQuote(c.QuoteAsset)
+
+	// Validate prefetch
+	if c.Prefetch < 1 {
+		return fmt.Errorf("prefetch must be at least 1, got %d", c.Prefetch)
+	}
+
+	if c.ProcessWorkers < 1 {
+		return fmt.Errorf("process-workers must be at least 1, got %d", c.ProcessWorkers)
+	}
+
+	if c.ParseBackend != "" && c.ParseBackend != "bufio" && c.ParseBackend != "mmap" {
+		return fmt.Errorf("invalid --parse-backend %q (expected bufio or mmap)", c.ParseBackend)
+	}
+
+	if c.ParseBatchSize < 0 {
+		return fmt.Errorf("parse-batch-size must not be negative, got %d", c.ParseBatchSize)
+	}
+
+	if c.FlushAfterHours < 0 {
+		return fmt.Errorf("flush-after must not be negative, got %d", c.FlushAfterHours)
+	}
+
+	if c.LatePolicy != "" && c.LatePolicy != "drop" && c.LatePolicy != "revise" {
+		return fmt.Errorf("invalid --late-policy %q (expected drop or revise)", c.LatePolicy)
+	}
+	if c.LateGraceHours < 0 {
+		return fmt.Errorf("late-grace must not be negative, got %d", c.LateGraceHours)
+	}
+	if c.LateGraceHours > 0 && c.FlushAfterHours > 0 {
+		return fmt.Errorf("--late-grace and --flush-after set the same finalization cutoff; use one or the other")
+	}
+
+	if c.WhalePercentiles != "" {
+		c.WhaleTiers = c.WhaleTiers[:0]
+		for _, part := range strings.Split(c.WhalePercentiles, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			pct, err := strconv.ParseFloat(part, 64)
+			if err != nil || pct <= 0 || pct >= 100 {
+				return fmt.Errorf("invalid --whale-percentiles entry %q (expected a percentile between 0 and 100)", part)
+			}
+			c.WhaleTiers = append(c.WhaleTiers, pct)
+		}
+	}
+
+	if c.CompareWindowDays < 0 {
+		return fmt.Errorf("compare-window must not be negative, got %d", c.CompareWindowDays)
+	}
+	if c.CompareWindowDays == PercentileWindowDays {
+		return fmt.Errorf("compare-window %d matches the primary window; nothing to compare", c.CompareWindowDays)
+	}
+
+	// Parse temp directory size cap
+	if c.MaxTempSize != "" {
+		size, err := ParseSize(c.MaxTempSize)
+		if err != nil {
+			return fmt.Errorf("invalid --max-temp-size: %w", err)
+		}
+		c.MaxTempSizeBytes = size
+	}
+
+	// Parse total download cap
+	if c.MaxTotalDownload != "" {
+		size, err := ParseSize(c.MaxTotalDownload)
+		if err != nil {
+			return fmt.Errorf("invalid --max-total-download: %w", err)
+		}
+		c.MaxTotalDownloadBytes = size
+	}
+
 	// Calculate max memory in bytes
 	totalMem, err := GetTotalMemory()
 	if err != nil {
@@ -107,6 +457,178 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// TimeWindow is a half-open time interval [Start, End)
+type TimeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains returns true if t falls within the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// parseExcludeWindows parses exclusion windows from the inline flag and/or
+// calendar file. Each window is "start/end" with RFC3339-like timestamps
+// (2006-01-02T15:04) or bare dates (whole day).
+func (c *Config) parseExcludeWindows() error {
+	var specs []string
+
+	if c.ExcludeHours != "" {
+		specs = append(specs, strings.Split(c.ExcludeHours, ",")...)
+	}
+
+	if c.ExcludeFile != "" {
+		data, err := os.ReadFile(c.ExcludeFile)
+		if err != nil {
+			return fmt.Errorf("read exclude file: %w", err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			specs = append(specs, line)
+		}
+	}
+
+	for _, spec := range specs {
+		parts := strings.SplitN(strings.TrimSpace(spec), "/", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid exclude window %q (expected start/end)", spec)
+		}
+
+		start, err := parseWindowTime(parts[0], false)
+		if err != nil {
+			return fmt.Errorf("invalid exclude window start %q: %w", parts[0], err)
+		}
+		end, err := parseWindowTime(parts[1], true)
+		if err != nil {
+			return fmt.Errorf("invalid exclude window end %q: %w", parts[1], err)
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("exclude window %q has start after end", spec)
+		}
+
+		c.ExcludeWindows = append(c.ExcludeWindows, TimeWindow{Start: start, End: end})
+	}
+
+	return nil
+}
+
+// parseWindowTime parses an exclusion window bound. Bare dates cover the
+// whole day, so an end bound rolls over to the next midnight.
+func parseWindowTime(s string, isEnd bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse("2006-01-02T15:04", s); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if isEnd {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.UTC(), nil
+}
+
+// Fingerprint returns a string capturing the settings that affect bar
+// semantics. Resuming a checkpoint produced under a different fingerprint
+// would silently mix incompatible bars.
+func (c *Config) Fingerprint() string {
+	fp := fmt.Sprintf("symbol=%s window=%dd samples=%d warmup=%dh",
+		c.Symbol, PercentileWindowDays, ReservoirSamplesPerDay, WarmupHours)
+	if c.Simulate {
+		// Synthetic checkpoints must never mix with real ones
+		fp += " simulate"
+	}
+	if c.WhaleNotional {
+		// Notional-mode reservoirs sample price*qty; resuming them in
+		// quantity mode (or vice versa) would mix units
+		fp += " notional"
+	}
+	if c.CompareWindowDays > 0 {
+		// The _w<days> columns only make sense against the same second
+		// window they were computed with
+		fp += fmt.Sprintf(" compare=%dd", c.CompareWindowDays)
+	}
+	return fp
+}
+
+// ExpandDatePattern resolves the fuzzy --start/--end forms cron scripts
+// want: a bare year ("2021" → 2021-01, or 2021-12 for --end), a relative
+// month window ("-24m" → 24 months before the latest available month), and
+// "latest". latest is the last discovered month; when empty (discovery
+// skipped or failed) the current month is used. Values matching no pattern
+// are returned unchanged for the normal validation to handle.
+func ExpandDatePattern(value, latest string, isEnd bool) string {
+	anchor := latest
+	if anchor == "" {
+		anchor = time.Now().UTC().Format("2006-01")
+	}
+
+	// "latest": the last available month
+	if strings.EqualFold(value, "latest") {
+		return anchor
+	}
+
+	// "-Nm": N months before the anchor
+	if strings.HasPrefix(value, "-") && strings.HasSuffix(value, "m") {
+		if n, err := strconv.Atoi(value[1 : len(value)-1]); err == nil && n > 0 {
+			if t, err := time.Parse("2006-01", anchor); err == nil {
+				return t.AddDate(0, -n, 0).Format("2006-01")
+			}
+		}
+	}
+
+	// Bare year: expand to its first (or, for --end, last) month
+	if len(value) == 4 {
+		if _, err := strconv.Atoi(value); err == nil {
+			if isEnd {
+				return value + "-12"
+			}
+			return value + "-01"
+		}
+	}
+
+	return value
+}
+
+// ParseSize parses a human-readable size string like "100GB", "500MB", "1.5TB"
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+
+	multipliers := []struct {
+		suffix string
+		factor float64
+	}{
+		{"TB", 1024 * 1024 * 1024 * 1024},
+		{"GB", 1024 * 1024 * 1024},
+		{"MB", 1024 * 1024},
+		{"KB", 1024},
+		{"B", 1},
+	}
+
+	for _, m := range multipliers {
+		if strings.HasSuffix(s, m.suffix) {
+			num := strings.TrimSuffix(s, m.suffix)
+			value, err := strconv.ParseFloat(num, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return int64(value * m.factor), nil
+		}
+	}
+
+	// Bare number = bytes
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return value, nil
+}
+
 // GetTotalMemory returns total system memory in bytes
 func GetTotalMemory() (uint64, error) {
 	v, err := mem.VirtualMemory()
@@ -130,11 +652,44 @@ func GetNumCPU() int {
 	return runtime.NumCPU()
 }
 
+// CheckpointDir returns the checkpoint directory path.
+// Applies the default temp directory if none is set, so it can be used
+// by commands that don't run full Validate().
+func (c *Config) CheckpointDir() string {
+	tempDir := c.TempDir
+	if tempDir == "" {
+		tempDir = fmt.Sprintf("./aggtrades_temp_%s", c.Symbol)
+	}
+	return fmt.Sprintf("%s/checkpoint", tempDir)
+}
+
 // MonthURL returns the download URL for a specific month
 func (c *Config) MonthURL(yearMonth string) string {
 	return fmt.Sprintf("%s/%s/%s-aggTrades-%s.zip", BaseURL, c.Symbol, c.Symbol, yearMonth)
 }
 
+// DayURL returns the download URL for a specific day's daily file
+func (c *Config) DayURL(day string) string {
+	return fmt.Sprintf("%s/%s/%s-aggTrades-%s.zip", DailyBaseURL, c.Symbol, c.Symbol, day)
+}
+
+// MonthURLVariants returns the canonical month URL first, then alternate
+// filename spellings seen in older dumps (lowercase dataset name, unpadded
+// month). Tried in order before declaring a month missing.
+func (c *Config) MonthURLVariants(yearMonth string) []string {
+	variants := []string{
+		c.MonthURL(yearMonth),
+		fmt.Sprintf("%s/%s/%s-aggtrades-%s.zip", BaseURL, c.Symbol, c.Symbol, yearMonth),
+	}
+	if parts := strings.SplitN(yearMonth, "-", 2); len(parts) == 2 && strings.HasPrefix(parts[1], "0") {
+		short := parts[0] + "-" + strings.TrimPrefix(parts[1], "0")
+		variants = append(variants,
+			fmt.Sprintf("%s/%s/%s-aggTrades-%s.zip", BaseURL, c.Symbol, c.Symbol, short),
+			fmt.Sprintf("%s/%s/%s-aggtrades-%s.zip", BaseURL, c.Symbol, c.Symbol, short))
+	}
+	return variants
+}
+
 // MonthZipPath returns the local ZIP path for a month
 func (c *Config) MonthZipPath(yearMonth string) string {
 	return fmt.Sprintf("%s/%s.zip", c.TempDir, yearMonth)
@@ -0,0 +1,65 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AuthRule injects a header into requests whose URL starts with Prefix.
+// Used for authenticated internal mirrors of the Binance archives; the
+// public endpoints match no rule and stay unauthenticated.
+type AuthRule struct {
+	Prefix string
+	Header string
+	Value  string
+}
+
+var (
+	authMu    sync.RWMutex
+	authRules []AuthRule
+)
+
+// SetAuthRules installs the header-injection rules applied to every
+// download request. Call once at startup.
+func SetAuthRules(rules []AuthRule) {
+	authMu.Lock()
+	defer authMu.Unlock()
+	authRules = rules
+}
+
+// ParseAuthRules parses "urlprefix=Header: value" specs, e.g.
+// "https://mirror.example.com/=Authorization: Bearer abc123".
+func ParseAuthRules(specs []string) ([]AuthRule, error) {
+	rules := make([]AuthRule, 0, len(specs))
+	for _, spec := range specs {
+		prefix, header, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid auth header %q (want urlprefix=Header: value)", spec)
+		}
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid auth header %q (want urlprefix=Header: value)", spec)
+		}
+		rules = append(rules, AuthRule{
+			Prefix: prefix,
+			Header: strings.TrimSpace(name),
+			Value:  strings.TrimSpace(value),
+		})
+	}
+	return rules, nil
+}
+
+// applyAuth adds the headers of every rule whose prefix matches the
+// request URL
+func applyAuth(req *http.Request) {
+	authMu.RLock()
+	defer authMu.RUnlock()
+	url := req.URL.String()
+	for _, rule := range authRules {
+		if strings.HasPrefix(url, rule.Prefix) {
+			req.Header.Set(rule.Header, rule.Value)
+		}
+	}
+}
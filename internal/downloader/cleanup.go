@@ -0,0 +1,105 @@
+package downloader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OrphanReport describes stale files found in the temp directory.
+type OrphanReport struct {
+	TmpFiles           []string // Leftover .tmp files from interrupted downloads/extractions
+	CompletedArtifacts []string // ZIP/CSV files for months already checkpointed
+	TotalBytes         int64
+}
+
+// Count returns the total number of orphaned files.
+func (r *OrphanReport) Count() int {
+	return len(r.TmpFiles) + len(r.CompletedArtifacts)
+}
+
+// ScanOrphans scans the temp directory for stale .tmp files and for
+// ZIP/CSV artifacts belonging to months that are already completed.
+// Interrupted runs can leave tens of GB behind.
+func ScanOrphans(tempDir string, completedMonths []string) (*OrphanReport, error) {
+	report := &OrphanReport{}
+
+	completed := make(map[string]bool, len(completedMonths))
+	for _, m := range completedMonths {
+		completed[m] = true
+	}
+
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return report, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		path := filepath.Join(tempDir, name)
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		if strings.HasSuffix(name, ".tmp") {
+			report.TmpFiles = append(report.TmpFiles, path)
+			report.TotalBytes += info.Size()
+			continue
+		}
+
+		// Month artifacts are named <YYYY-MM>.zip / <YYYY-MM>.csv
+		ext := filepath.Ext(name)
+		if ext != ".zip" && ext != ".csv" {
+			continue
+		}
+		month := strings.TrimSuffix(name, ext)
+		if completed[month] {
+			report.CompletedArtifacts = append(report.CompletedArtifacts, path)
+			report.TotalBytes += info.Size()
+		}
+	}
+
+	return report, nil
+}
+
+// DirSize returns the total size in bytes of all files in a directory.
+func DirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !fi.IsDir() {
+			total += fi.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// RemoveOrphans deletes all files in the report. Returns the number removed.
+func RemoveOrphans(report *OrphanReport) int {
+	removed := 0
+	for _, path := range report.TmpFiles {
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	for _, path := range report.CompletedArtifacts {
+		if os.Remove(path) == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,138 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Network tuning for the download client. Routes to the CDN are not always
+// sane — broken IPv6 paths, slow corporate DNS — so the dialer can force an
+// IP family, use a custom resolver, and cache lookups, without OS-level
+// changes.
+
+const dialTimeout = 30 * time.Second
+
+var (
+	netMu       sync.RWMutex
+	netFamily   = "ip" // ip, ip4 or ip6
+	netResolver *net.Resolver
+	dnsTTL      time.Duration
+	dnsCache    = make(map[string]dnsEntry)
+)
+
+type dnsEntry struct {
+	addrs   []net.IP
+	expires time.Time
+}
+
+// SetDialOptions configures the dialer used by all download requests:
+// ipVersion forces an IP family ("4" or "6", "" or "auto" for both),
+// dnsServer overrides the system resolver (host or host:port), and
+// cacheTTL caches DNS answers for that long (0 disables the cache).
+// Call once at startup.
+func SetDialOptions(ipVersion, dnsServer string, cacheTTL time.Duration) error {
+	netMu.Lock()
+	defer netMu.Unlock()
+
+	switch ipVersion {
+	case "", "auto":
+		netFamily = "ip"
+	case "4":
+		netFamily = "ip4"
+	case "6":
+		netFamily = "ip6"
+	default:
+		return fmt.Errorf("invalid IP version %q (want 4, 6, or auto)", ipVersion)
+	}
+
+	netResolver = nil
+	if dnsServer != "" {
+		server := dnsServer
+		if !strings.Contains(server, ":") {
+			server += ":53"
+		}
+		netResolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: dialTimeout}
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	dnsTTL = cacheTTL
+	dnsCache = make(map[string]dnsEntry)
+	return nil
+}
+
+// dialContext dials with the configured family, resolver, and DNS cache
+func dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	netMu.RLock()
+	family := netFamily
+	resolver := netResolver
+	ttl := dnsTTL
+	netMu.RUnlock()
+
+	d := &net.Dialer{Timeout: dialTimeout}
+
+	// Nothing configured: plain happy-eyeballs dialing
+	if family == "ip" && resolver == nil && ttl <= 0 {
+		return d.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := lookupHost(ctx, resolver, family, host, ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := d.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no %s addresses for %s", family, host)
+	}
+	return nil, lastErr
+}
+
+// lookupHost resolves host to the configured family, consulting and
+// refreshing the DNS cache when a TTL is set
+func lookupHost(ctx context.Context, resolver *net.Resolver, family, host string, ttl time.Duration) ([]net.IP, error) {
+	key := family + "/" + host
+	if ttl > 0 {
+		netMu.RLock()
+		entry, ok := dnsCache[key]
+		netMu.RUnlock()
+		if ok && time.Now().Before(entry.expires) {
+			return entry.addrs, nil
+		}
+	}
+
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupIP(ctx, family, host)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl > 0 {
+		netMu.Lock()
+		dnsCache[key] = dnsEntry{addrs: addrs, expires: time.Now().Add(ttl)}
+		netMu.Unlock()
+	}
+	return addrs, nil
+}
@@ -2,29 +2,49 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	// HTTP settings
-	downloadTimeout = 5 * time.Minute
-	bufferSize      = 32 * 1024 // 32KB buffer for streaming
+	downloadTimeout = 5 * time.Minute // small requests (HEAD, range completion)
+	bufferSize      = 32 * 1024       // 32KB buffer for streaming
 	maxRetries      = 3
+
+	// Stall detection: a download that receives no bytes for this long is
+	// aborted and resumed with a Range request from the bytes already on
+	// disk. There is deliberately no absolute download timeout: a 20 GB
+	// month on a slow link is fine as long as bytes keep flowing.
+	stallTimeout = 30 * time.Second
+
+	// Consecutive zero-progress stalls before giving up
+	maxStalls = 3
 )
 
+// errStalled marks a download aborted by the stall detector
+var errStalled = errors.New("download stalled")
+
+// ErrNotFound marks an HTTP 404: the file does not exist under this name,
+// so retrying is pointless but an alternate name might still work
+var ErrNotFound = errors.New("not found")
+
 // Result represents the result of a download
 type Result struct {
-	Month    string
-	ZipPath  string
-	ZipSize  int64
-	CSVPath  string
-	CSVSize  int64
-	Error    error
-	Skipped  bool // True if file already existed
+	Month   string
+	ZipPath string
+	ZipSize int64
+	CSVPath string
+	CSVSize int64
+	Error   error
+	Skipped bool // True if file already existed
 }
 
 // ProgressCallback is called periodically during download with bytes downloaded
@@ -32,61 +52,144 @@ type ProgressCallback func(bytesDownloaded int64)
 
 // Download downloads a ZIP file from URL to the specified path
 // Uses streaming to minimize memory usage
-// Downloads to a .tmp file first, then renames atomically on success
+// Downloads to a .tmp file first, then renames atomically on success.
+// Instead of an absolute timeout, a stall detector aborts when no bytes
+// arrive for stallTimeout and resumes from the bytes already fetched.
 func Download(ctx context.Context, url, destPath string, progressCb ProgressCallback) (int64, error) {
 	tmpPath := destPath + ".tmp"
+	os.Remove(tmpPath)
 
-	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	var offset int64
+	stalls := 0
+	for {
+		newOffset, err := downloadChunk(ctx, url, tmpPath, offset, progressCb)
+		if err == nil {
+			offset = newOffset
+			break
+		}
+		if errors.Is(err, errStalled) {
+			// Resume from what is on disk; only repeated stalls with
+			// zero progress abort the download
+			if newOffset > offset {
+				stalls = 0
+			} else {
+				stalls++
+			}
+			offset = newOffset
+			if stalls < maxStalls {
+				continue
+			}
+			err = fmt.Errorf("%s: no bytes for %s after %d resume attempts", url, stallTimeout, maxStalls)
+		}
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	// Atomic rename: tmp -> final
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("rename temp file: %w", err)
+	}
+
+	// Final progress callback
+	if progressCb != nil {
+		progressCb(offset)
+	}
+
+	return offset, nil
+}
+
+// downloadChunk fetches url into tmpPath starting at offset (with a Range
+// request when resuming) and returns the new absolute offset. A watchdog
+// cancels the request when no bytes arrive for stallTimeout, surfacing
+// errStalled so the caller can resume.
+func downloadChunk(ctx context.Context, url, tmpPath string, offset int64, progressCb ProgressCallback) (int64, error) {
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(chunkCtx, http.MethodGet, url, nil)
 	if err != nil {
-		return 0, fmt.Errorf("create request: %w", err)
+		return offset, fmt.Errorf("create request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
 	}
+	applyAuth(req)
+	reqID := tagRequest(req)
 
-	// Execute request
-	client := &http.Client{Timeout: downloadTimeout}
+	client := &http.Client{Transport: &http.Transport{DialContext: dialContext, Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: stallTimeout}}
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("http request: %w", err)
+		return offset, fmt.Errorf("http request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("http status %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return offset, fmt.Errorf("%s: %w", url, ErrNotFound)
+	case offset > 0 && resp.StatusCode == http.StatusOK:
+		// Server ignored the Range header; start over with the full body
+		offset = 0
+	case offset > 0 && resp.StatusCode != http.StatusPartialContent:
+		return offset, fmt.Errorf("http status %d (request %s)", resp.StatusCode, reqID)
+	case offset == 0 && resp.StatusCode != http.StatusOK:
+		return offset, fmt.Errorf("http status %d (request %s)", resp.StatusCode, reqID)
 	}
 
-	// Create temp file
-	file, err := os.Create(tmpPath)
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return 0, fmt.Errorf("create file: %w", err)
+		return offset, fmt.Errorf("create file: %w", err)
+	}
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return offset, fmt.Errorf("truncate file: %w", err)
 	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return offset, fmt.Errorf("seek file: %w", err)
+	}
+
+	// Watchdog: cancel the request when no bytes arrive for stallTimeout
+	var lastActivity atomic.Int64
+	lastActivity.Store(time.Now().UnixNano())
+	stalled := make(chan struct{})
+	watchdogDone := make(chan struct{})
+	go func() {
+		defer close(watchdogDone)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-chunkCtx.Done():
+				return
+			case <-ticker.C:
+				if time.Since(time.Unix(0, lastActivity.Load())) > stallTimeout {
+					close(stalled)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
 
 	// Stream download with progress tracking
-	var totalBytes int64
 	buf := make([]byte, bufferSize)
 	lastProgress := time.Now()
-	var downloadErr error
-
+	total := offset
+	var readErr error
 	for {
-		select {
-		case <-ctx.Done():
-			file.Close()
-			os.Remove(tmpPath)
-			return 0, ctx.Err()
-		default:
-		}
-
 		n, err := resp.Body.Read(buf)
 		if n > 0 {
+			lastActivity.Store(time.Now().UnixNano())
 			if _, writeErr := file.Write(buf[:n]); writeErr != nil {
 				file.Close()
-				os.Remove(tmpPath)
-				return 0, fmt.Errorf("write file: %w", writeErr)
+				return total, fmt.Errorf("write file: %w", writeErr)
 			}
-			totalBytes += int64(n)
+			total += int64(n)
 
 			// Report progress every 100ms
 			if progressCb != nil && time.Since(lastProgress) > 100*time.Millisecond {
-				progressCb(totalBytes)
+				progressCb(total)
 				lastProgress = time.Now()
 			}
 		}
@@ -95,31 +198,29 @@ func Download(ctx context.Context, url, destPath string, progressCb ProgressCall
 			break
 		}
 		if err != nil {
-			downloadErr = fmt.Errorf("read response: %w", err)
+			readErr = err
 			break
 		}
 	}
+	cancel()
+	<-watchdogDone
 
-	// Close file before rename
-	file.Close()
-
-	if downloadErr != nil {
-		os.Remove(tmpPath)
-		return 0, downloadErr
-	}
-
-	// Atomic rename: tmp -> final
-	if err := os.Rename(tmpPath, destPath); err != nil {
-		os.Remove(tmpPath)
-		return 0, fmt.Errorf("rename temp file: %w", err)
+	if err := file.Close(); err != nil {
+		return total, fmt.Errorf("close file: %w", err)
 	}
 
-	// Final progress callback
-	if progressCb != nil {
-		progressCb(totalBytes)
+	if readErr != nil {
+		select {
+		case <-stalled:
+			return total, errStalled
+		default:
+		}
+		if ctx.Err() != nil {
+			return total, ctx.Err()
+		}
+		return total, fmt.Errorf("read response: %w", readErr)
 	}
-
-	return totalBytes, nil
+	return total, nil
 }
 
 // DownloadWithRetry downloads with exponential backoff retry
@@ -141,6 +242,10 @@ func DownloadWithRetry(ctx context.Context, url, destPath string, progressCb Pro
 		if err == nil {
 			return size, nil
 		}
+		if errors.Is(err, ErrNotFound) {
+			// The name is wrong, not the network; let the caller try variants
+			return 0, err
+		}
 
 		lastErr = err
 		// Clean up partial file
@@ -156,8 +261,10 @@ func GetFileSize(ctx context.Context, url string) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	applyAuth(req)
+	reqID := tagRequest(req)
 
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{DialContext: dialContext, Proxy: http.ProxyFromEnvironment}}
 	resp, err := client.Do(req)
 	if err != nil {
 		return 0, err
@@ -165,7 +272,7 @@ func GetFileSize(ctx context.Context, url string) (int64, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("http status %d", resp.StatusCode)
+		return 0, fmt.Errorf("http status %d (request %s)", resp.StatusCode, reqID)
 	}
 
 	return resp.ContentLength, nil
@@ -179,3 +286,65 @@ func FileExists(path string) (bool, int64) {
 	}
 	return true, info.Size()
 }
+
+// FileSHA256 returns the hex SHA-256 of a file's contents
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ErrRangeUnsupported is returned when the server ignores Range requests
+var ErrRangeUnsupported = fmt.Errorf("server does not support ranged requests")
+
+// DownloadRange completes a truncated download by fetching only the missing
+// byte range [from, expectedSize) and appending it to destPath. Returns
+// ErrRangeUnsupported if the server replies with a full body instead of 206,
+// in which case the caller should fall back to a full re-download.
+func DownloadRange(ctx context.Context, url, destPath string, from, expectedSize int64) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, expectedSize-1))
+	applyAuth(req)
+	reqID := tagRequest(req)
+
+	client := &http.Client{Timeout: downloadTimeout, Transport: &http.Transport{DialContext: dialContext, Proxy: http.ProxyFromEnvironment}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusPartialContent {
+		if resp.StatusCode == http.StatusOK {
+			return 0, ErrRangeUnsupported
+		}
+		return 0, fmt.Errorf("http status %d (request %s)", resp.StatusCode, reqID)
+	}
+
+	file, err := os.OpenFile(destPath, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("open file: %w", err)
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("append range: %w", err)
+	}
+
+	if from+written != expectedSize {
+		return written, fmt.Errorf("range download incomplete: have %d of %d bytes", from+written, expectedSize)
+	}
+	return written, nil
+}
@@ -2,16 +2,31 @@ package downloader
 
 import (
 	"archive/zip"
+	"compress/flate"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExtractProgressCallback is called periodically during extraction with
+// bytes written so far and the expected uncompressed total
+type ExtractProgressCallback func(bytesWritten, totalBytes int64)
+
+const (
+	// ZIP compression method 9 (deflate64), used by some historical archives
+	methodDeflate64 = 9
 )
 
 // Extract extracts a ZIP file to the specified CSV path
 // Returns the size of the extracted CSV file
 // Extracts to a .tmp file first, then renames atomically on success
-func Extract(zipPath, csvPath string) (int64, error) {
+// Progress is reported through the callback and the context cancels
+// mid-extraction, so a 30-GB extract doesn't look like a hang
+func Extract(ctx context.Context, zipPath, csvPath string, progressCb ExtractProgressCallback) (int64, error) {
 	tmpPath := csvPath + ".tmp"
 
 	// Open the ZIP file
@@ -21,14 +36,20 @@ func Extract(zipPath, csvPath string) (int64, error) {
 	}
 	defer reader.Close()
 
+	// Some historical archives use deflate64. The standard reader has no
+	// decompressor for it; register a flate fallback (deflate64 is a
+	// superset, so entries within the 32KB window still decode and larger
+	// windows fail with a decode error instead of "unsupported method").
+	reader.RegisterDecompressor(methodDeflate64, func(r io.Reader) io.ReadCloser {
+		return flate.NewReader(r)
+	})
+
 	// Find the CSV file in the ZIP
-	if len(reader.File) == 0 {
-		return 0, fmt.Errorf("empty zip file")
+	zipFile, err := findCSVEntry(&reader.Reader)
+	if err != nil {
+		return 0, err
 	}
 
-	// Take the first file (should be the only one)
-	zipFile := reader.File[0]
-
 	// Open the file inside the ZIP
 	src, err := zipFile.Open()
 	if err != nil {
@@ -44,10 +65,20 @@ func Extract(zipPath, csvPath string) (int64, error) {
 
 	// Copy with buffering
 	buf := make([]byte, 1024*1024) // 1MB buffer
+	expectedBytes := int64(zipFile.UncompressedSize64)
 	var totalBytes int64
 	var extractErr error
+	lastProgress := time.Now()
 
 	for {
+		select {
+		case <-ctx.Done():
+			dst.Close()
+			os.Remove(tmpPath)
+			return 0, ctx.Err()
+		default:
+		}
+
 		n, err := src.Read(buf)
 		if n > 0 {
 			if _, writeErr := dst.Write(buf[:n]); writeErr != nil {
@@ -56,6 +87,12 @@ func Extract(zipPath, csvPath string) (int64, error) {
 				return 0, fmt.Errorf("write csv: %w", writeErr)
 			}
 			totalBytes += int64(n)
+
+			// Report progress every 100ms
+			if progressCb != nil && time.Since(lastProgress) > 100*time.Millisecond {
+				progressCb(totalBytes, expectedBytes)
+				lastProgress = time.Now()
+			}
 		}
 
 		if err == io.EOF {
@@ -67,6 +104,11 @@ func Extract(zipPath, csvPath string) (int64, error) {
 		}
 	}
 
+	// Final progress callback
+	if progressCb != nil {
+		progressCb(totalBytes, expectedBytes)
+	}
+
 	// Close file before rename
 	dst.Close()
 
@@ -75,6 +117,13 @@ func Extract(zipPath, csvPath string) (int64, error) {
 		return 0, extractErr
 	}
 
+	// Verify against the uncompressed size recorded in the zip header,
+	// so a truncated extraction is not silently parsed with missing tail data
+	if expectedBytes > 0 && totalBytes != expectedBytes {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("truncated extraction: got %d of %d bytes", totalBytes, expectedBytes)
+	}
+
 	// Atomic rename: tmp -> final
 	if err := os.Rename(tmpPath, csvPath); err != nil {
 		os.Remove(tmpPath)
@@ -84,9 +133,37 @@ func Extract(zipPath, csvPath string) (int64, error) {
 	return totalBytes, nil
 }
 
+// findCSVEntry selects the CSV file entry from a ZIP archive.
+// Iterates all entries (skipping directories) rather than assuming the
+// archive contains exactly one file at the root: some archives nest the
+// CSV inside a folder entry.
+func findCSVEntry(reader *zip.Reader) (*zip.File, error) {
+	if len(reader.File) == 0 {
+		return nil, fmt.Errorf("empty zip file")
+	}
+
+	var fallback *zip.File
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if strings.EqualFold(filepath.Ext(f.Name), ".csv") {
+			return f, nil
+		}
+		if fallback == nil {
+			fallback = f
+		}
+	}
+
+	if fallback != nil {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("no file entries in zip")
+}
+
 // ExtractAndRemoveZip extracts and then removes the ZIP file
-func ExtractAndRemoveZip(zipPath, csvPath string) (int64, error) {
-	size, err := Extract(zipPath, csvPath)
+func ExtractAndRemoveZip(ctx context.Context, zipPath, csvPath string, progressCb ExtractProgressCallback) (int64, error) {
+	size, err := Extract(ctx, zipPath, csvPath, progressCb)
 	if err != nil {
 		return 0, err
 	}
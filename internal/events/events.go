@@ -0,0 +1,119 @@
+// Package events publishes finalized hourly bars to a NATS subject so
+// downstream streaming consumers can pick them up as they are produced.
+// Each event carries an idempotent key derived from (symbol, time), set as
+// the Nats-Msg-Id header so JetStream deduplication makes re-runs safe.
+// Under the revise late-data policy, bars revised by late trades are
+// re-published as correction events whose key carries the revision number
+// so deduplication does not swallow them; the final flag tells consumers
+// whether a bar can still be corrected.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/nats-io/nats.go"
+)
+
+// BarEvent is the JSON payload published per finalized bar. Columns holds
+// the full registry projection by output column name.
+type BarEvent struct {
+	Key      string         `json:"key"` // "<symbol>:<RFC3339 hour>", ":r<n>" appended for corrections
+	Symbol   string         `json:"symbol"`
+	Time     time.Time      `json:"time"`
+	Revision int            `json:"revision"` // 0 on first publication, incremented per correction
+	Final    bool           `json:"final"`    // true when the late policy makes published bars immutable
+	Columns  map[string]any `json:"columns"`
+}
+
+// Publisher publishes bar events to a NATS subject
+type Publisher struct {
+	nc      *nats.Conn
+	subject string
+	symbol  string
+	mutable bool
+
+	mu        sync.Mutex
+	published int
+	errs      int
+}
+
+// NewPublisher connects to the NATS server at url (nats://host:4222) and
+// publishes to the given subject
+func NewPublisher(url, subject, symbol string) (*Publisher, error) {
+	nc, err := nats.Connect(url,
+		nats.Name("aggtrades"),
+		nats.Timeout(5*time.Second),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connect to %s: %w", url, err)
+	}
+	return &Publisher{nc: nc, subject: subject, symbol: symbol}, nil
+}
+
+// SetMutable declares that published bars may later be revised (the
+// revise late-data policy is active), clearing the final flag on every
+// event so consumers know to expect corrections. Call before publishing.
+func (p *Publisher) SetMutable(mutable bool) {
+	p.mutable = mutable
+}
+
+// Publish emits one finalized bar, or a correction when the bar carries a
+// revision. Publish failures are counted, not fatal — event delivery must
+// never abort a run.
+func (p *Publisher) Publish(hour time.Time, bar *aggregator.HourlyBar) {
+	cols := make(map[string]any, len(aggregator.Columns))
+	for _, col := range aggregator.Columns {
+		cols[col.Name] = col.Value(bar)
+	}
+
+	key := fmt.Sprintf("%s:%s", p.symbol, hour.UTC().Format(time.RFC3339))
+	if bar.Revision > 0 {
+		key = fmt.Sprintf("%s:r%d", key, bar.Revision)
+	}
+
+	ev := BarEvent{
+		Key:      key,
+		Symbol:   p.symbol,
+		Time:     hour.UTC(),
+		Revision: bar.Revision,
+		Final:    !p.mutable,
+		Columns:  cols,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	body, err := json.Marshal(ev)
+	if err != nil {
+		p.errs++
+		return
+	}
+
+	msg := nats.NewMsg(p.subject)
+	msg.Data = body
+	msg.Header.Set(nats.MsgIdHdr, ev.Key)
+	if err := p.nc.PublishMsg(msg); err != nil {
+		p.errs++
+		return
+	}
+	p.published++
+}
+
+// Stats returns how many events were published and how many failed
+func (p *Publisher) Stats() (published, errors int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.published, p.errs
+}
+
+// Close flushes pending publishes and closes the connection
+func (p *Publisher) Close() error {
+	err := p.nc.FlushTimeout(5 * time.Second)
+	p.nc.Close()
+	return err
+}
@@ -0,0 +1,68 @@
+package memory
+
+import "sync"
+
+const (
+	// estimateBaseBytes covers read buffers, batch slices, and aggregator
+	// state that every month needs regardless of size
+	estimateBaseBytes = 128 * 1024 * 1024
+
+	// estimateBytesPerTrade is the amortized in-flight memory per trade
+	// (parse batches plus GC headroom)
+	estimateBytesPerTrade = 4
+
+	// ewmaAlpha is the weight given to the newest observation
+	ewmaAlpha = 0.3
+)
+
+// Estimator predicts per-month processing memory from a trades-per-CSV-byte
+// ratio calibrated on the months already processed this run (and seeded from
+// the checkpoint on resume). Until the first observation it falls back to
+// the flat EstimateProcessMemory heuristic.
+type Estimator struct {
+	mu            sync.Mutex
+	tradesPerByte float64 // 0 until calibrated
+}
+
+// NewEstimator creates an estimator, optionally seeded with a persisted
+// trades-per-byte ratio (0 means uncalibrated)
+func NewEstimator(tradesPerByte float64) *Estimator {
+	return &Estimator{tradesPerByte: tradesPerByte}
+}
+
+// Observe feeds one processed month into the model
+func (e *Estimator) Observe(csvBytes, trades int64) {
+	if csvBytes <= 0 || trades <= 0 {
+		return
+	}
+	ratio := float64(trades) / float64(csvBytes)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.tradesPerByte == 0 {
+		e.tradesPerByte = ratio
+	} else {
+		e.tradesPerByte = ewmaAlpha*ratio + (1-ewmaAlpha)*e.tradesPerByte
+	}
+}
+
+// TradesPerByte returns the current calibrated ratio (0 if uncalibrated)
+func (e *Estimator) TradesPerByte() float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tradesPerByte
+}
+
+// Estimate predicts the memory needed to process a CSV of the given size
+func (e *Estimator) Estimate(csvSize int64) int64 {
+	e.mu.Lock()
+	tpb := e.tradesPerByte
+	e.mu.Unlock()
+
+	if tpb == 0 {
+		return EstimateProcessMemory(csvSize)
+	}
+
+	trades := float64(csvSize) * tpb
+	return estimateBaseBytes + int64(trades*estimateBytesPerTrade)
+}
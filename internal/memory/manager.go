@@ -6,6 +6,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/clement/aggtrades/clock"
 	"github.com/shirou/gopsutil/v3/process"
 )
 
@@ -33,6 +34,9 @@ type Manager struct {
 
 	stopChan chan struct{}
 	wg       sync.WaitGroup
+
+	// Injectable clock (defaults to the real clock)
+	clock clock.Clock
 }
 
 // NewManager creates a new memory manager
@@ -42,6 +46,7 @@ func NewManager(maxBytes int64) *Manager {
 		minFreeBytes: 1 * 1024 * 1024 * 1024, // 1GB safety margin
 		StatsChan:    make(chan Stats, 10),
 		stopChan:     make(chan struct{}),
+		clock:        clock.New(),
 	}
 
 	m.wg.Add(1)
@@ -54,14 +59,14 @@ func NewManager(maxBytes int64) *Manager {
 func (m *Manager) statsLoop() {
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(100 * time.Millisecond)
+	ticker := m.clock.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-m.stopChan:
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			stats := m.GetStats()
 			select {
 			case m.StatsChan <- stats:
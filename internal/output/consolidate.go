@@ -0,0 +1,50 @@
+package output
+
+import (
+	"sort"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// Consolidate merges per-venue hourly series (e.g. BTCUSDT, BTCBUSD,
+// BTCUSDC) into one consolidated flow series, summing each hour's bars
+// across venues with HourlyBar.Merge. VWAP and PriceStd are recomputed as
+// volume-weighted averages of the member bars, as in Rollup.
+func Consolidate(perVenue map[string][]aggregator.HourlyResult) []aggregator.HourlyResult {
+	buckets := make(map[time.Time]*aggregator.HourlyBar)
+	vwapWeight := make(map[time.Time]float64)
+	vwapSum := make(map[time.Time]float64)
+	stdSum := make(map[time.Time]float64)
+
+	for _, bars := range perVenue {
+		for _, hr := range bars {
+			bar, exists := buckets[hr.Time]
+			if !exists {
+				bar = aggregator.NewHourlyBar()
+				buckets[hr.Time] = bar
+			}
+			bar.Merge(hr.Bar)
+
+			w := hr.Bar.TotalVolume()
+			vwapWeight[hr.Time] += w
+			vwapSum[hr.Time] += hr.Bar.VWAP * w
+			stdSum[hr.Time] += hr.Bar.PriceStd * w
+		}
+	}
+
+	result := make([]aggregator.HourlyResult, 0, len(buckets))
+	for hour, bar := range buckets {
+		if w := vwapWeight[hour]; w > 0 {
+			bar.VWAP = vwapSum[hour] / w
+			bar.PriceStd = stdSum[hour] / w
+		}
+		result = append(result, aggregator.HourlyResult{Time: hour, Bar: bar})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+
+	return result
+}
@@ -0,0 +1,254 @@
+package output
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// deltaPrefix marks an output destination as a Delta-compatible table
+const deltaPrefix = "delta://"
+
+// deltaLogDir is the Delta transaction log directory name
+const deltaLogDir = "_delta_log"
+
+// IsDelta reports whether the output destination is a delta:// URL
+func IsDelta(output string) bool {
+	return strings.HasPrefix(output, deltaPrefix)
+}
+
+// DeltaPath strips the delta:// prefix from an output destination
+func DeltaPath(output string) string {
+	return strings.TrimPrefix(output, deltaPrefix)
+}
+
+// WriteDelta writes hourly bars as a Delta-compatible table: parquet files
+// partitioned by month under the table root, plus a _delta_log commit so the
+// table can be registered in a lakehouse catalog without conversion. If the
+// table already exists, a new commit replaces the previous snapshot
+// (overwrite semantics); old data files are logically removed but not
+// deleted, matching Delta's vacuum model.
+func WriteDelta(bars []aggregator.HourlyResult, dir string, columns []string) error {
+	if err := os.MkdirAll(filepath.Join(dir, deltaLogDir), 0755); err != nil {
+		return fmt.Errorf("create delta table dir: %w", err)
+	}
+
+	version, previous, err := deltaSnapshot(dir)
+	if err != nil {
+		return err
+	}
+
+	// Partition bars by month
+	partitions := make(map[string][]aggregator.HourlyResult)
+	for _, bar := range bars {
+		month := bar.Time.UTC().Format("2006-01")
+		partitions[month] = append(partitions[month], bar)
+	}
+	months := make([]string, 0, len(partitions))
+	for month := range partitions {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	// Write one parquet file per partition
+	type addFile struct {
+		path  string
+		month string
+		size  int64
+	}
+	var adds []addFile
+	for _, month := range months {
+		relDir := "month=" + month
+		if err := os.MkdirAll(filepath.Join(dir, relDir), 0755); err != nil {
+			return fmt.Errorf("create partition dir: %w", err)
+		}
+		relPath := filepath.ToSlash(filepath.Join(relDir, fmt.Sprintf("part-00000-%s.parquet", deltaUUID())))
+		absPath := filepath.Join(dir, filepath.FromSlash(relPath))
+		if err := WriteParquetColumns(partitions[month], absPath, columns); err != nil {
+			return fmt.Errorf("write partition %s: %w", month, err)
+		}
+		info, err := os.Stat(absPath)
+		if err != nil {
+			return err
+		}
+		adds = append(adds, addFile{path: relPath, month: month, size: info.Size()})
+	}
+
+	// Build the commit: metadata, protocol, removes for the previous
+	// snapshot, adds for the new files
+	now := time.Now().UnixMilli()
+	var actions []any
+	actions = append(actions, map[string]any{
+		"commitInfo": map[string]any{
+			"timestamp":           now,
+			"operation":           "WRITE",
+			"operationParameters": map[string]any{"mode": "Overwrite"},
+			"engineInfo":          "aggtrades",
+		},
+	})
+	if version == 0 {
+		actions = append(actions, map[string]any{
+			"protocol": map[string]any{"minReaderVersion": 1, "minWriterVersion": 2},
+		})
+	}
+	actions = append(actions, map[string]any{
+		"metaData": map[string]any{
+			"id":               deltaUUID(),
+			"format":           map[string]any{"provider": "parquet", "options": map[string]any{}},
+			"schemaString":     deltaSchemaString(columns),
+			"partitionColumns": []string{"month"},
+			"configuration":    map[string]any{},
+			"createdTime":      now,
+		},
+	})
+	for _, path := range previous {
+		actions = append(actions, map[string]any{
+			"remove": map[string]any{"path": path, "deletionTimestamp": now, "dataChange": true},
+		})
+	}
+	for _, add := range adds {
+		actions = append(actions, map[string]any{
+			"add": map[string]any{
+				"path":             add.path,
+				"partitionValues":  map[string]string{"month": add.month},
+				"size":             add.size,
+				"modificationTime": now,
+				"dataChange":       true,
+			},
+		})
+	}
+
+	return writeDeltaCommit(dir, version, actions)
+}
+
+// deltaSnapshot scans the existing log and returns the next commit version
+// and the data files live in the current snapshot
+func deltaSnapshot(dir string) (version int64, live []string, err error) {
+	entries, err := os.ReadDir(filepath.Join(dir, deltaLogDir))
+	if err != nil {
+		return 0, nil, fmt.Errorf("read delta log: %w", err)
+	}
+
+	files := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		var v int64
+		if _, err := fmt.Sscanf(name, "%d.json", &v); err != nil {
+			continue
+		}
+		if v >= version {
+			version = v + 1
+		}
+		if err := applyDeltaCommit(filepath.Join(dir, deltaLogDir, name), files); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	for path := range files {
+		live = append(live, path)
+	}
+	sort.Strings(live)
+	return version, live, nil
+}
+
+// applyDeltaCommit replays one commit file's add/remove actions
+func applyDeltaCommit(path string, files map[string]bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var action struct {
+			Add    *struct{ Path string } `json:"add"`
+			Remove *struct{ Path string } `json:"remove"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			continue
+		}
+		if action.Add != nil {
+			files[action.Add.Path] = true
+		}
+		if action.Remove != nil {
+			delete(files, action.Remove.Path)
+		}
+	}
+	return scanner.Err()
+}
+
+// writeDeltaCommit writes one commit file atomically (tmp + rename)
+func writeDeltaCommit(dir string, version int64, actions []any) error {
+	path := filepath.Join(dir, deltaLogDir, fmt.Sprintf("%020d.json", version))
+	tmp := path + ".tmp"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create commit: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	for _, action := range actions {
+		if err := enc.Encode(action); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("write commit: %w", err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// deltaSchemaString builds the Spark-style schema JSON for the projected
+// columns plus the time and month partition columns
+func deltaSchemaString(columns []string) string {
+	type field struct {
+		Name     string         `json:"name"`
+		Type     string         `json:"type"`
+		Nullable bool           `json:"nullable"`
+		Metadata map[string]any `json:"metadata"`
+	}
+
+	fields := []field{{Name: "time", Type: "timestamp", Nullable: false, Metadata: map[string]any{}}}
+	for _, name := range columns {
+		col, _ := aggregator.ColumnByName(name)
+		typ := "double"
+		if col.Type == aggregator.ColumnInt64 {
+			typ = "long"
+		}
+		fields = append(fields, field{Name: col.Name, Type: typ, Nullable: col.Nullable, Metadata: map[string]any{}})
+	}
+	fields = append(fields, field{Name: "month", Type: "string", Nullable: false, Metadata: map[string]any{}})
+
+	out, _ := json.Marshal(map[string]any{"type": "struct", "fields": fields})
+	return string(out)
+}
+
+// deltaUUID returns a random RFC 4122 v4 UUID string
+func deltaUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,273 @@
+package output
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Encryption at rest for output files. The parquet library has no support
+// for parquet modular encryption (footer/column keys), so this applies an
+// AES-256-GCM envelope over the whole file instead: same compliance
+// property (the dataset is never stored unencrypted), readable only after
+// `aggtrades decrypt`.
+//
+// Container layout: magic, random 8-byte nonce prefix, then 1 MiB chunks
+// each sealed with nonce prefix + big-endian chunk counter and preceded by
+// a 4-byte ciphertext length. The last chunk is additionally authenticated
+// as final (via the associated data, as in the STREAM construction), so a
+// file truncated anywhere — including at a chunk boundary — fails
+// authentication instead of decrypting to silently shortened plaintext.
+
+const (
+	encMagic     = "AGGTENC2"
+	encChunkSize = 1 << 20
+)
+
+// chunkAAD is the associated data sealing each chunk's position in the
+// stream: intermediate or final. Authenticating finality is what makes
+// truncation at a chunk boundary detectable.
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// LoadKeyFile reads a 256-bit key from a keyfile: either 32 raw bytes or
+// 64 hex characters (surrounding whitespace ignored)
+func LoadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read keyfile: %w", err)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("keyfile %s must hold 32 raw bytes or 64 hex characters", path)
+	}
+	return key, nil
+}
+
+// IsEncrypted reports whether the file starts with the encryption magic
+func IsEncrypted(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(encMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return string(magic) == encMagic
+}
+
+// EncryptFile seals inPath into outPath (atomically, tmp + rename).
+// inPath and outPath may be the same file.
+func EncryptFile(inPath, outPath string, key []byte) (err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(inPath)
+	if err != nil {
+		return fmt.Errorf("open input: %w", err)
+	}
+	defer in.Close()
+
+	tmpPath := outPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	noncePrefix := make([]byte, 8)
+	if _, err = rand.Read(noncePrefix); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+	if _, err = out.Write([]byte(encMagic)); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	if _, err = out.Write(noncePrefix); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	// One chunk of lookahead: a chunk is only known to be the last once
+	// the read after it comes up empty, and the last chunk must be sealed
+	// with the final marker. An empty input still writes one (empty)
+	// final chunk so even its termination is authenticated.
+	cur := make([]byte, encChunkSize)
+	next := make([]byte, encChunkSize)
+	var counter uint32
+	var lenBuf [4]byte
+
+	n, readErr := io.ReadFull(in, cur)
+	if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+		err = fmt.Errorf("read input: %w", readErr)
+		return err
+	}
+	for {
+		m, readErr := io.ReadFull(in, next)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			err = fmt.Errorf("read input: %w", readErr)
+			return err
+		}
+		final := m == 0
+
+		sealed := gcm.Seal(nil, chunkNonce(noncePrefix, counter), cur[:n], chunkAAD(final))
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(sealed)))
+		if _, err = out.Write(lenBuf[:]); err != nil {
+			return fmt.Errorf("write chunk: %w", err)
+		}
+		if _, err = out.Write(sealed); err != nil {
+			return fmt.Errorf("write chunk: %w", err)
+		}
+		counter++
+
+		if final {
+			break
+		}
+		cur, next = next, cur
+		n = m
+	}
+
+	if err = out.Sync(); err != nil {
+		return fmt.Errorf("sync output: %w", err)
+	}
+	if err = out.Close(); err != nil {
+		return fmt.Errorf("close output: %w", err)
+	}
+	err = os.Rename(tmpPath, outPath)
+	return err
+}
+
+// DecryptFile opens the sealed container at inPath into outPath
+// (atomically, tmp + rename)
+func DecryptFile(inPath, outPath string, key []byte) (err error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(inPath)
+	if err != nil {
+		return fmt.Errorf("open input: %w", err)
+	}
+	defer in.Close()
+
+	header := make([]byte, len(encMagic)+8)
+	if _, err = io.ReadFull(in, header); err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	if !bytes.Equal(header[:len(encMagic)], []byte(encMagic)) {
+		return fmt.Errorf("%s is not an encrypted aggtrades file", inPath)
+	}
+	noncePrefix := header[len(encMagic):]
+
+	tmpPath := outPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create output: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// Mirror the writer's lookahead: only after the next chunk length
+	// fails to appear is the current chunk opened as final. A container
+	// truncated at a chunk boundary then fails authentication, because
+	// its last present chunk was sealed as intermediate.
+	var counter uint32
+	var lenBuf [4]byte
+	sealed := make([]byte, 0, encChunkSize+gcm.Overhead())
+	if _, readErr := io.ReadFull(in, lenBuf[:]); readErr != nil {
+		err = fmt.Errorf("read chunk length: %w", readErr)
+		return err
+	}
+	for {
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		if n > encChunkSize+uint32(gcm.Overhead()) {
+			err = fmt.Errorf("corrupt chunk length %d", n)
+			return err
+		}
+		sealed = sealed[:n]
+		if _, readErr := io.ReadFull(in, sealed); readErr != nil {
+			err = fmt.Errorf("read chunk: %w", readErr)
+			return err
+		}
+
+		final := false
+		if _, readErr := io.ReadFull(in, lenBuf[:]); readErr == io.EOF {
+			final = true
+		} else if readErr != nil {
+			err = fmt.Errorf("read chunk length: %w", readErr)
+			return err
+		}
+
+		plain, openErr := gcm.Open(nil, chunkNonce(noncePrefix, counter), sealed, chunkAAD(final))
+		if openErr != nil {
+			if final {
+				err = fmt.Errorf("decrypt chunk %d (wrong key, corrupt file, or container truncated at a chunk boundary): %w", counter, openErr)
+			} else {
+				err = fmt.Errorf("decrypt chunk %d (wrong key or corrupt file): %w", counter, openErr)
+			}
+			return err
+		}
+		if _, err = out.Write(plain); err != nil {
+			return fmt.Errorf("write output: %w", err)
+		}
+		counter++
+
+		if final {
+			break
+		}
+	}
+
+	if err = out.Sync(); err != nil {
+		return fmt.Errorf("sync output: %w", err)
+	}
+	if err = out.Close(); err != nil {
+		return fmt.Errorf("close output: %w", err)
+	}
+	err = os.Rename(tmpPath, outPath)
+	return err
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(prefix []byte, counter uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[8:], counter)
+	return nonce
+}
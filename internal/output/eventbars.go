@@ -0,0 +1,140 @@
+package output
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/parquet-go/parquet-go"
+)
+
+// eventBarColumns is the fixed column order for event-bar outputs. Event
+// bars are irregular in time, so the schema carries a bar_open_time /
+// bar_close_time pair instead of the hourly time index.
+var eventBarColumns = []string{
+	"bar_open_time", "bar_close_time",
+	"open_price", "high_price", "low_price", "close_price", "vwap",
+	"volume", "dollar_volume", "buy_vol", "sell_vol",
+	"n_trades", "first_agg_id", "last_agg_id",
+}
+
+// eventBarRow flattens one bar into the schema's column values
+func eventBarRow(bar aggregator.EventBar) map[string]any {
+	return map[string]any{
+		"bar_open_time":  bar.OpenTime.UTC().UnixMilli(),
+		"bar_close_time": bar.CloseTime.UTC().UnixMilli(),
+		"open_price":     bar.OpenPrice,
+		"high_price":     bar.HighPrice,
+		"low_price":      bar.LowPrice,
+		"close_price":    bar.ClosePrice,
+		"vwap":           bar.VWAP,
+		"volume":         bar.Volume,
+		"dollar_volume":  bar.DollarVolume,
+		"buy_vol":        bar.BuyVolume,
+		"sell_vol":       bar.SellVolume,
+		"n_trades":       bar.NTrades,
+		"first_agg_id":   bar.FirstAggID,
+		"last_agg_id":    bar.LastAggID,
+	}
+}
+
+// WriteEventBarsParquet writes event bars to a parquet file via the same
+// tmp-and-rename dance as the hourly writers, so a crash mid-write never
+// destroys an existing output
+func WriteEventBarsParquet(bars []aggregator.EventBar, outputPath string) (err error) {
+	group := parquet.Group{
+		"bar_open_time":  parquet.Timestamp(parquet.Millisecond),
+		"bar_close_time": parquet.Timestamp(parquet.Millisecond),
+		"n_trades":       parquet.Int(64),
+		"first_agg_id":   parquet.Int(64),
+		"last_agg_id":    parquet.Int(64),
+	}
+	for _, name := range eventBarColumns {
+		if _, ok := group[name]; !ok {
+			group[name] = parquet.Leaf(parquet.DoubleType)
+		}
+	}
+	schema := parquet.NewSchema("eventbars", group)
+
+	tmpPath := outputPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		file.Close()
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := parquet.NewGenericWriter[map[string]any](file, schema)
+
+	const batchSize = 1000
+	rows := make([]map[string]any, 0, batchSize)
+	for _, bar := range bars {
+		rows = append(rows, eventBarRow(bar))
+		if len(rows) >= batchSize {
+			if _, err = writer.Write(rows); err != nil {
+				return err
+			}
+			rows = rows[:0]
+		}
+	}
+	if len(rows) > 0 {
+		if _, err = writer.Write(rows); err != nil {
+			return err
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, outputPath)
+	return err
+}
+
+// WriteEventBarsCSV streams event bars as CSV with a header row, with the
+// bar open/close times as RFC3339
+func WriteEventBarsCSV(bars []aggregator.EventBar, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for i, name := range eventBarColumns {
+		if i > 0 {
+			bw.WriteByte(',')
+		}
+		bw.WriteString(name)
+	}
+	bw.WriteByte('\n')
+
+	for _, bar := range bars {
+		bw.WriteString(bar.OpenTime.UTC().Format(time.RFC3339))
+		bw.WriteByte(',')
+		bw.WriteString(bar.CloseTime.UTC().Format(time.RFC3339))
+		for _, v := range []float64{
+			bar.OpenPrice, bar.HighPrice, bar.LowPrice, bar.ClosePrice, bar.VWAP,
+			bar.Volume, bar.DollarVolume, bar.BuyVolume, bar.SellVolume,
+		} {
+			bw.WriteByte(',')
+			bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		for _, v := range []int64{bar.NTrades, bar.FirstAggID, bar.LastAggID} {
+			bw.WriteByte(',')
+			bw.WriteString(strconv.FormatInt(v, 10))
+		}
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// ParseTags parses a comma-separated k=v list ("env=prod,source=vision")
+// into line-protocol tags
+func ParseTags(spec string) (map[string]string, error) {
+	tags := make(map[string]string)
+	if spec == "" {
+		return tags, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("bad tag %q (want key=value)", pair)
+		}
+		tags[k] = v
+	}
+	return tags, nil
+}
+
+// WriteLineProtocol streams hourly bars as InfluxDB line protocol so the
+// dataset can feed existing Grafana dashboards via Influx or Telegraf.
+// Each bar becomes one point: the projected columns are fields, timestamps
+// are nanoseconds, and tags (sorted for consistent series keys) are shared
+// by every point.
+func WriteLineProtocol(bars []aggregator.HourlyResult, w io.Writer, columns []string, measurement string, tags map[string]string) error {
+	cols := make([]aggregator.Column, len(columns))
+	for i, name := range columns {
+		cols[i], _ = aggregator.ColumnByName(name)
+	}
+
+	// Measurement and sorted tags are identical for every point
+	var prefix strings.Builder
+	prefix.WriteString(escapeLP(measurement))
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		prefix.WriteByte(',')
+		prefix.WriteString(escapeLP(k))
+		prefix.WriteByte('=')
+		prefix.WriteString(escapeLP(tags[k]))
+	}
+	prefix.WriteByte(' ')
+
+	bw := bufio.NewWriter(w)
+	for _, bar := range bars {
+		bw.WriteString(prefix.String())
+		for i, col := range cols {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.WriteString(col.Name)
+			bw.WriteByte('=')
+			switch v := col.Value(bar.Bar).(type) {
+			case int64:
+				bw.WriteString(strconv.FormatInt(v, 10))
+				bw.WriteByte('i')
+			case float64:
+				bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+			}
+		}
+		bw.WriteByte(' ')
+		bw.WriteString(strconv.FormatInt(bar.Time.UTC().UnixNano(), 10))
+		if _, err := bw.WriteString("\n"); err != nil {
+			return fmt.Errorf("write line protocol: %w", err)
+		}
+	}
+	return bw.Flush()
+}
+
+// escapeLP escapes the characters special in measurement and tag tokens
+func escapeLP(s string) string {
+	r := strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+	return r.Replace(s)
+}
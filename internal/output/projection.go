@@ -0,0 +1,161 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/parquet-go/parquet-go"
+)
+
+// columnNode returns the parquet node for a registry column, honoring its
+// storage type and nullability
+func columnNode(c aggregator.Column) parquet.Node {
+	var node parquet.Node
+	switch c.Type {
+	case aggregator.ColumnInt64:
+		node = parquet.Int(64)
+	default:
+		node = parquet.Leaf(parquet.DoubleType)
+	}
+	if c.Nullable {
+		node = parquet.Optional(node)
+	}
+	return node
+}
+
+// ParseColumns validates a comma-separated column selection and returns the
+// requested column names. The time column is implicit and never listed.
+func ParseColumns(spec string) ([]string, error) {
+	var cols []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "time" {
+			continue // always written
+		}
+		if _, ok := aggregator.ColumnByName(name); !ok {
+			return nil, fmt.Errorf("unknown column %q (known: %s)", name, strings.Join(ColumnNames(), ", "))
+		}
+		cols = append(cols, name)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return cols, nil
+}
+
+// ColumnNames returns all selectable column names in registry order
+func ColumnNames() []string {
+	names := make([]string, 0, len(aggregator.Columns))
+	for _, c := range aggregator.Columns {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// schemaFor builds the parquet schema for a column selection
+func schemaFor(columns []string) *parquet.Schema {
+	return schemaWith(columns, nil)
+}
+
+// schemaWith builds the parquet schema for a column selection plus derived
+// extra columns (always optional doubles, since they may be NaN)
+func schemaWith(columns []string, extras []ExtraColumn) *parquet.Schema {
+	group := parquet.Group{
+		"time": parquet.Timestamp(parquet.Millisecond),
+	}
+	for _, name := range columns {
+		col, _ := aggregator.ColumnByName(name)
+		group[name] = columnNode(col)
+	}
+	for _, extra := range extras {
+		group[extra.Name] = parquet.Optional(parquet.Leaf(parquet.DoubleType))
+	}
+	return parquet.NewSchema("hourly", group)
+}
+
+// WriteParquetColumns writes hourly bars to a parquet file containing only
+// the requested columns (plus time), using a schema generated from the
+// column registry.
+//
+// The file is written to <output>.tmp, fsynced, and renamed into place, so a
+// crash mid-write never destroys an existing output.
+func WriteParquetColumns(bars []aggregator.HourlyResult, outputPath string, columns []string) error {
+	return WriteParquetTransformed(bars, outputPath, columns, nil)
+}
+
+// WriteParquetTransformed is WriteParquetColumns plus derived extra columns
+// (write-time transforms). extras[i].Values must be aligned with bars, so
+// bars must already be sorted chronologically; NaN values become nulls.
+func WriteParquetTransformed(bars []aggregator.HourlyResult, outputPath string, columns []string, extras []ExtraColumn) (err error) {
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Time.Before(bars[j].Time)
+	})
+
+	cols := make([]aggregator.Column, len(columns))
+	for i, name := range columns {
+		cols[i], _ = aggregator.ColumnByName(name)
+	}
+
+	tmpPath := outputPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		file.Close()
+		if err != nil {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := parquet.NewGenericWriter[map[string]any](file, schemaWith(columns, extras))
+
+	const batchSize = 1000
+	rows := make([]map[string]any, 0, batchSize)
+
+	for i, bar := range bars {
+		row := make(map[string]any, len(cols)+len(extras)+1)
+		row["time"] = bar.Time.UnixMilli()
+		for _, col := range cols {
+			row[col.Name] = col.Value(bar.Bar)
+		}
+		for _, extra := range extras {
+			if v := extra.Values[i]; !math.IsNaN(v) {
+				row[extra.Name] = v
+			}
+		}
+		rows = append(rows, row)
+
+		if len(rows) >= batchSize {
+			if _, err = writer.Write(rows); err != nil {
+				return err
+			}
+			rows = rows[:0]
+		}
+	}
+
+	if len(rows) > 0 {
+		if _, err = writer.Write(rows); err != nil {
+			return err
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, outputPath)
+	return err
+}
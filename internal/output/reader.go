@@ -0,0 +1,103 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/parquet-go/parquet-go"
+)
+
+// registerFileTiers registers whale tier column groups present in a
+// file's schema but missing from the registry, so outputs written with
+// --whale-percentiles survive read-modify-write commands (update, diff,
+// query) that run in a fresh process
+func registerFileTiers(schema *parquet.Schema) {
+	const prefix = "whale_buy_vol_"
+	for _, field := range schema.Fields() {
+		name := field.Name()
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if _, ok := aggregator.ColumnByName(name); ok {
+			continue
+		}
+		label := strings.TrimPrefix(name, prefix)
+		if days, ok := aggregator.CompareFromLabel(label); ok {
+			aggregator.RegisterCompareColumns(days)
+			continue
+		}
+		if pct, ok := aggregator.TierFromLabel(label); ok {
+			aggregator.RegisterWhaleTiers([]float64{pct})
+		}
+	}
+}
+
+// fromRow converts a parquet row back into an HourlyResult using the column
+// registry. Missing or null columns are left at their zero value.
+func fromRow(row map[string]any) aggregator.HourlyResult {
+	bar := aggregator.NewHourlyBar()
+	for _, col := range aggregator.Columns {
+		if v, ok := row[col.Name]; ok && v != nil {
+			col.Set(bar, v)
+		}
+	}
+
+	var ts time.Time
+	switch t := row["time"].(type) {
+	case int64:
+		ts = time.UnixMilli(t).UTC()
+	case time.Time:
+		ts = t.UTC()
+	}
+
+	return aggregator.HourlyResult{Time: ts, Bar: bar}
+}
+
+// ReadParquet reads an existing output file back into hourly bars
+func ReadParquet(path string) ([]aggregator.HourlyResult, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	pf, err := parquet.OpenFile(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("open parquet: %w", err)
+	}
+	registerFileTiers(pf.Schema())
+
+	reader := parquet.NewGenericReader[map[string]any](file, schemaFor(ColumnNames()))
+	defer reader.Close()
+
+	bars := make([]aggregator.HourlyResult, 0, reader.NumRows())
+	rows := make([]map[string]any, 1000)
+
+	for {
+		// The reader reconstructs into the provided maps, so they must
+		// be allocated (and not reused across batches)
+		for i := range rows {
+			rows[i] = make(map[string]any, len(aggregator.Columns)+1)
+		}
+		n, err := reader.Read(rows)
+		for i := 0; i < n; i++ {
+			bars = append(bars, fromRow(rows[i]))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read parquet: %w", err)
+		}
+	}
+
+	return bars, nil
+}
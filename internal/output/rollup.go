@@ -0,0 +1,79 @@
+package output
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// RollupPeriod identifies a rollup granularity
+type RollupPeriod int
+
+const (
+	RollupDaily RollupPeriod = iota
+	RollupMonthly
+)
+
+// Rollup merges hourly bars into daily or monthly bars using HourlyBar.Merge.
+// VWAP and PriceStd are recomputed as volume-weighted averages of the member
+// bars, since the raw accumulators are not available after deserialization.
+func Rollup(bars []aggregator.HourlyResult, period RollupPeriod) []aggregator.HourlyResult {
+	buckets := make(map[time.Time]*aggregator.HourlyBar)
+	vwapWeight := make(map[time.Time]float64)
+	vwapSum := make(map[time.Time]float64)
+	stdSum := make(map[time.Time]float64)
+
+	for _, hr := range bars {
+		bucket := truncatePeriod(hr.Time, period)
+
+		bar, exists := buckets[bucket]
+		if !exists {
+			bar = aggregator.NewHourlyBar()
+			buckets[bucket] = bar
+		}
+		bar.Merge(hr.Bar)
+
+		// Volume-weighted VWAP/PriceStd accumulation
+		w := hr.Bar.TotalVolume()
+		vwapWeight[bucket] += w
+		vwapSum[bucket] += hr.Bar.VWAP * w
+		stdSum[bucket] += hr.Bar.PriceStd * w
+	}
+
+	result := make([]aggregator.HourlyResult, 0, len(buckets))
+	for bucket, bar := range buckets {
+		if w := vwapWeight[bucket]; w > 0 {
+			bar.VWAP = vwapSum[bucket] / w
+			bar.PriceStd = stdSum[bucket] / w
+		}
+		result = append(result, aggregator.HourlyResult{Time: bucket, Bar: bar})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+
+	return result
+}
+
+// truncatePeriod floors a timestamp to the start of its day or month (UTC)
+func truncatePeriod(t time.Time, period RollupPeriod) time.Time {
+	t = t.UTC()
+	switch period {
+	case RollupMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// RollupPath derives a companion file path from the main output path,
+// e.g. bars.parquet -> bars_daily.parquet
+func RollupPath(outputPath, suffix string) string {
+	if idx := strings.LastIndex(outputPath, ".parquet"); idx >= 0 {
+		return outputPath[:idx] + "_" + suffix + ".parquet"
+	}
+	return outputPath + "_" + suffix
+}
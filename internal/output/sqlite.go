@@ -0,0 +1,163 @@
+package output
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+
+	_ "modernc.org/sqlite" // pure-Go driver, no cgo toolchain needed
+)
+
+// sqlitePrefix marks an output destination as a SQLite database
+const sqlitePrefix = "sqlite://"
+
+// IsSQLite reports whether the output destination is a sqlite:// URL
+func IsSQLite(output string) bool {
+	return strings.HasPrefix(output, sqlitePrefix)
+}
+
+// SQLitePath strips the sqlite:// prefix from an output destination
+func SQLitePath(output string) string {
+	return strings.TrimPrefix(output, sqlitePrefix)
+}
+
+// RunMeta is the run metadata recorded alongside the bars
+type RunMeta struct {
+	Symbol    string
+	StartDate string
+	EndDate   string
+
+	// Version identifies the binary that produced the bars, for
+	// reproducibility audits on long-lived databases
+	Version string
+}
+
+// WriteSQLite writes hourly bars into an indexed SQLite database so the
+// dataset can be queried ad hoc without a parquet toolchain. The schema has
+// a symbols table, a bars table keyed by (symbol_id, time) with the
+// projected columns, and a runs table recording one row per invocation.
+// Re-running upserts bars, so extending a date range is safe.
+func WriteSQLite(bars []aggregator.HourlyResult, path string, columns []string, meta RunMeta) error {
+	cols := make([]aggregator.Column, len(columns))
+	for i, name := range columns {
+		cols[i], _ = aggregator.ColumnByName(name)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open sqlite db: %w", err)
+	}
+	defer db.Close()
+
+	if err := createSchema(db, cols); err != nil {
+		return err
+	}
+
+	// Resolve (or create) the symbol row
+	if _, err := db.Exec(`INSERT OR IGNORE INTO symbols (symbol) VALUES (?)`, meta.Symbol); err != nil {
+		return fmt.Errorf("insert symbol: %w", err)
+	}
+	var symbolID int64
+	if err := db.QueryRow(`SELECT id FROM symbols WHERE symbol = ?`, meta.Symbol).Scan(&symbolID); err != nil {
+		return fmt.Errorf("lookup symbol: %w", err)
+	}
+
+	// Insert all bars in one transaction
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(insertSQL(cols))
+	if err != nil {
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	args := make([]any, 0, len(cols)+2)
+	for _, bar := range bars {
+		args = args[:0]
+		args = append(args, symbolID, bar.Time.UTC().UnixMilli())
+		for _, col := range cols {
+			args = append(args, col.Value(bar.Bar))
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			return fmt.Errorf("insert bar %s: %w", bar.Time.Format("2006-01-02T15"), err)
+		}
+	}
+
+	// Record run metadata
+	_, err = tx.Exec(
+		`INSERT INTO runs (symbol, start_date, end_date, bars, created_at, version) VALUES (?, ?, ?, ?, ?, ?)`,
+		meta.Symbol, meta.StartDate, meta.EndDate, len(bars), time.Now().UTC().Format(time.RFC3339), meta.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("insert run: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// createSchema creates the tables and indexes if they don't exist yet
+func createSchema(db *sql.DB, cols []aggregator.Column) error {
+	var barCols strings.Builder
+	for _, col := range cols {
+		barCols.WriteString(", ")
+		barCols.WriteString(col.Name)
+		if col.Type == aggregator.ColumnInt64 {
+			barCols.WriteString(" INTEGER")
+		} else {
+			barCols.WriteString(" REAL")
+		}
+	}
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS symbols (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL UNIQUE
+		)`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS bars (
+			symbol_id INTEGER NOT NULL REFERENCES symbols(id),
+			time INTEGER NOT NULL%s,
+			PRIMARY KEY (symbol_id, time)
+		)`, barCols.String()),
+		`CREATE INDEX IF NOT EXISTS idx_bars_time ON bars (time)`,
+		`CREATE TABLE IF NOT EXISTS runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			start_date TEXT,
+			end_date TEXT,
+			version TEXT,
+			bars INTEGER,
+			created_at TEXT NOT NULL
+		)`,
+	}
+	for _, s := range stmts {
+		if _, err := db.Exec(s); err != nil {
+			return fmt.Errorf("create schema: %w", err)
+		}
+	}
+
+	// Databases created before the version column existed: best effort,
+	// the ALTER fails harmlessly when the column is already there
+	db.Exec(`ALTER TABLE runs ADD COLUMN version TEXT`)
+	return nil
+}
+
+// insertSQL builds the upsert statement for the projected columns
+func insertSQL(cols []aggregator.Column) string {
+	var names, marks strings.Builder
+	for _, col := range cols {
+		names.WriteString(", ")
+		names.WriteString(col.Name)
+		marks.WriteString(", ?")
+	}
+	return fmt.Sprintf(
+		`INSERT OR REPLACE INTO bars (symbol_id, time%s) VALUES (?, ?%s)`,
+		names.String(), marks.String(),
+	)
+}
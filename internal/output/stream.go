@@ -0,0 +1,134 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// Format is the output file format
+type Format int
+
+const (
+	FormatParquet Format = iota
+	FormatCSV
+	FormatJSONL
+	FormatLineProtocol
+)
+
+// ParseFormat parses a --format value
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "parquet":
+		return FormatParquet, nil
+	case "csv":
+		return FormatCSV, nil
+	case "jsonl":
+		return FormatJSONL, nil
+	case "lp", "influx":
+		return FormatLineProtocol, nil
+	default:
+		return FormatParquet, fmt.Errorf("unknown format %q (parquet, csv, jsonl, lp)", s)
+	}
+}
+
+// DetectFormat infers the output format from a file extension, defaulting
+// to parquet
+func DetectFormat(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".jsonl":
+		return FormatJSONL
+	case ".lp":
+		return FormatLineProtocol
+	default:
+		return FormatParquet
+	}
+}
+
+// WriteCSV streams hourly bars as CSV with a header row. Columns come from
+// the registry; the time column is written first as RFC3339. extras are
+// derived transform columns aligned with bars (may be nil).
+func WriteCSV(bars []aggregator.HourlyResult, w io.Writer, columns []string, extras []ExtraColumn) error {
+	cols := make([]aggregator.Column, len(columns))
+	for i, name := range columns {
+		cols[i], _ = aggregator.ColumnByName(name)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("time")
+	for _, col := range cols {
+		bw.WriteByte(',')
+		bw.WriteString(col.Name)
+	}
+	for _, extra := range extras {
+		bw.WriteByte(',')
+		bw.WriteString(extra.Name)
+	}
+	bw.WriteByte('\n')
+
+	for i, bar := range bars {
+		bw.WriteString(bar.Time.UTC().Format(time.RFC3339))
+		for _, col := range cols {
+			bw.WriteByte(',')
+			switch v := col.Value(bar.Bar).(type) {
+			case int64:
+				bw.WriteString(strconv.FormatInt(v, 10))
+			case float64:
+				bw.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+			}
+		}
+		for _, extra := range extras {
+			bw.WriteByte(',')
+			bw.WriteString(strconv.FormatFloat(extra.Values[i], 'g', -1, 64))
+		}
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteJSONL streams hourly bars as one JSON object per line. extras are
+// derived transform columns aligned with bars (may be nil); NaN values
+// become nulls.
+func WriteJSONL(bars []aggregator.HourlyResult, w io.Writer, columns []string, extras []ExtraColumn) error {
+	cols := make([]aggregator.Column, len(columns))
+	for i, name := range columns {
+		cols[i], _ = aggregator.ColumnByName(name)
+	}
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for i, bar := range bars {
+		row := make(map[string]any, len(cols)+len(extras)+1)
+		row["time"] = bar.Time.UTC().Format(time.RFC3339)
+		for _, col := range cols {
+			row[col.Name] = col.Value(bar.Bar)
+		}
+		for _, extra := range extras {
+			if v := extra.Values[i]; !math.IsNaN(v) {
+				row[extra.Name] = v
+			} else {
+				row[extra.Name] = nil
+			}
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
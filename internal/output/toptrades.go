@@ -0,0 +1,30 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// WriteTopTrades writes per-hour top trades as JSONL (one hour per line),
+// a companion to the main parquet output
+func WriteTopTrades(hours []aggregator.HourTopTrades, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+
+	for _, hour := range hours {
+		if err := encoder.Encode(hour); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
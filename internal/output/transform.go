@@ -0,0 +1,138 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+// zscoreDefaultWindow is the rolling window (hours) for z-score transforms
+// when the spec doesn't give one
+const zscoreDefaultWindow = 168
+
+// Transform is one declarative per-column transform, parsed from a spec
+// like "log1p:buy_vol", "zscore:whale_buy_vol_p99:720", or
+// "ratio:whale_buy_vol_p999"
+type Transform struct {
+	Kind   string // log1p, zscore, ratio
+	Column aggregator.Column
+	Window int // rolling window in hours (zscore only)
+}
+
+// Name returns the output column name for the transform
+func (t Transform) Name() string {
+	if t.Kind == "zscore" {
+		return fmt.Sprintf("%s_zscore_%dh", t.Column.Name, t.Window)
+	}
+	return t.Column.Name + "_" + t.Kind
+}
+
+// ExtraColumn is a derived column computed at write time, aligned with the
+// bars it was computed from. Values may be NaN where undefined.
+type ExtraColumn struct {
+	Name   string
+	Values []float64
+}
+
+// ParseTransforms parses a comma-separated transform spec list
+func ParseTransforms(spec string) ([]Transform, error) {
+	var transforms []Transform
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("bad transform %q (want kind:column)", part)
+		}
+
+		col, ok := aggregator.ColumnByName(fields[1])
+		if !ok {
+			return nil, fmt.Errorf("transform %q: unknown column %q", part, fields[1])
+		}
+
+		t := Transform{Kind: fields[0], Column: col, Window: zscoreDefaultWindow}
+		switch t.Kind {
+		case "log1p", "ratio":
+			if len(fields) > 2 {
+				return nil, fmt.Errorf("transform %q: %s takes no window", part, t.Kind)
+			}
+		case "zscore":
+			if len(fields) > 2 {
+				w, err := strconv.Atoi(fields[2])
+				if err != nil || w < 2 {
+					return nil, fmt.Errorf("transform %q: bad window %q", part, fields[2])
+				}
+				t.Window = w
+			}
+		default:
+			return nil, fmt.Errorf("transform %q: unknown kind %q (log1p, zscore, ratio)", part, t.Kind)
+		}
+		transforms = append(transforms, t)
+	}
+	return transforms, nil
+}
+
+// ComputeTransforms evaluates the transforms over the final bar series,
+// returning one derived column per transform
+func ComputeTransforms(bars []aggregator.HourlyResult, transforms []Transform) []ExtraColumn {
+	extras := make([]ExtraColumn, 0, len(transforms))
+	for _, t := range transforms {
+		values := make([]float64, len(bars))
+
+		raw := make([]float64, len(bars))
+		for i, bar := range bars {
+			switch v := t.Column.Value(bar.Bar).(type) {
+			case int64:
+				raw[i] = float64(v)
+			case float64:
+				raw[i] = v
+			}
+		}
+
+		switch t.Kind {
+		case "log1p":
+			for i, v := range raw {
+				values[i] = math.Log1p(math.Max(v, 0))
+			}
+		case "ratio":
+			// Ratio to the bar's total volume
+			for i, v := range raw {
+				if total := bars[i].Bar.TotalVolume(); total > 0 {
+					values[i] = v / total
+				} else {
+					values[i] = math.NaN()
+				}
+			}
+		case "zscore":
+			// Rolling z-score over the previous Window hours (the bar
+			// itself excluded); NaN until the window has filled
+			var sum, sumSq float64
+			for i, v := range raw {
+				if i >= t.Window {
+					old := raw[i-t.Window]
+					sum -= old
+					sumSq -= old * old
+					mean := sum / float64(t.Window)
+					variance := sumSq/float64(t.Window) - mean*mean
+					if variance > 0 {
+						values[i] = (v - mean) / math.Sqrt(variance)
+					} else {
+						values[i] = math.NaN()
+					}
+				} else {
+					values[i] = math.NaN()
+				}
+				sum += v
+				sumSq += v * v
+			}
+		}
+
+		extras = append(extras, ExtraColumn{Name: t.Name(), Values: values})
+	}
+	return extras
+}
@@ -109,8 +109,36 @@ func Validate(bars []aggregator.HourlyResult) ValidationResult {
 	return result
 }
 
+// GapFill selects how count-type (integer) columns are filled inside
+// interpolated gaps. Linear interpolation gives counts fractional-looking
+// values; zeroing keeps them honest (no trades were observed).
+type GapFill int
+
+const (
+	GapFillInterpolate GapFill = iota // linear, like the float columns
+	GapFillZero                       // counts drop to zero in gaps
+)
+
+// ParseGapFill parses a --count-fill flag value
+func ParseGapFill(s string) (GapFill, error) {
+	switch s {
+	case "", "interpolate":
+		return GapFillInterpolate, nil
+	case "zero":
+		return GapFillZero, nil
+	default:
+		return 0, fmt.Errorf("unknown count fill mode %q (interpolate, zero)", s)
+	}
+}
+
 // FillGaps fills small gaps in the data using linear interpolation
 func FillGaps(bars []aggregator.HourlyResult, maxGapHours int) ([]aggregator.HourlyResult, int, int) {
+	return FillGapsMode(bars, maxGapHours, GapFillInterpolate)
+}
+
+// FillGapsMode is FillGaps with configurable fill behavior for count-type
+// columns
+func FillGapsMode(bars []aggregator.HourlyResult, maxGapHours int, countFill GapFill) ([]aggregator.HourlyResult, int, int) {
 	if len(bars) == 0 {
 		return bars, 0, 0
 	}
@@ -126,8 +154,11 @@ func FillGaps(bars []aggregator.HourlyResult, maxGapHours int) ([]aggregator.Hou
 		barMap[bar.Time] = bar.Bar
 	}
 
-	// Find gaps and fill
+	// Find gaps and fill, remembering each hour's provenance for the
+	// per-bar data quality columns
 	result := make([]aggregator.HourlyResult, 0, len(bars))
+	interpolated := make([]bool, 0, len(bars))
+	unfilled := make([]bool, 0, len(bars))
 	var smallGapsFilled, largeGapsNaN int
 
 	firstTime := bars[0].Time
@@ -136,6 +167,8 @@ func FillGaps(bars []aggregator.HourlyResult, maxGapHours int) ([]aggregator.Hou
 	for t := firstTime; !t.After(lastTime); t = t.Add(time.Hour) {
 		if bar, exists := barMap[t]; exists {
 			result = append(result, aggregator.HourlyResult{Time: t, Bar: bar})
+			interpolated = append(interpolated, false)
+			unfilled = append(unfilled, false)
 		} else {
 			// Missing hour - check gap size
 			gapStart := t
@@ -155,12 +188,14 @@ func FillGaps(bars []aggregator.HourlyResult, maxGapHours int) ([]aggregator.Hou
 
 			if gapSize <= maxGapHours {
 				// Small gap - interpolate
-				interpolated := interpolateGap(barMap, gapStart, gapSize)
-				for i, bar := range interpolated {
+				filled := interpolateGap(barMap, gapStart, gapSize, countFill)
+				for i, bar := range filled {
 					result = append(result, aggregator.HourlyResult{
 						Time: gapStart.Add(time.Duration(i) * time.Hour),
 						Bar:  bar,
 					})
+					interpolated = append(interpolated, true)
+					unfilled = append(unfilled, false)
 					smallGapsFilled++
 				}
 			} else {
@@ -170,17 +205,56 @@ func FillGaps(bars []aggregator.HourlyResult, maxGapHours int) ([]aggregator.Hou
 						Time: gapStart.Add(time.Duration(i) * time.Hour),
 						Bar:  aggregator.NewHourlyBar(),
 					})
+					interpolated = append(interpolated, false)
+					unfilled = append(unfilled, true)
 					largeGapsNaN++
 				}
 			}
 		}
 	}
 
+	applyQuality(result, interpolated, unfilled)
+
 	return result, smallGapsFilled, largeGapsNaN
 }
 
+// applyQuality sets the per-bar data quality columns from each hour's
+// provenance: gap_hours_prev_24h counts missing hours among the previous
+// 24, data_quality_score grades the bar itself (1 observed, 0.5 observed
+// but low activity, 0.25 interpolated, 0 unfilled). The advertised range
+// starts 24 hours before the first bar, so early bars count the hours
+// before coverage began as present.
+func applyQuality(result []aggregator.HourlyResult, interpolated, unfilled []bool) {
+	gapCount := 0
+	for i, hr := range result {
+		// The window for bar i covers i-24..i-1, so the element leaving
+		// it is i-25, not i-24
+		if i >= 25 && (interpolated[i-25] || unfilled[i-25]) {
+			gapCount--
+		}
+		hr.Bar.GapHoursPrev24h = int64(gapCount)
+
+		switch {
+		case unfilled[i]:
+			hr.Bar.DataQualityScore = 0
+			hr.Bar.Source = aggregator.SourceUnfilled
+		case interpolated[i]:
+			hr.Bar.DataQualityScore = 0.25
+			hr.Bar.Source = aggregator.SourceInterpolated
+		case hr.Bar.NTrades < config.MinTradesPerHour:
+			hr.Bar.DataQualityScore = 0.5
+		default:
+			hr.Bar.DataQualityScore = 1
+		}
+
+		if interpolated[i] || unfilled[i] {
+			gapCount++
+		}
+	}
+}
+
 // interpolateGap creates interpolated bars for a gap
-func interpolateGap(barMap map[time.Time]*aggregator.HourlyBar, gapStart time.Time, gapSize int) []*aggregator.HourlyBar {
+func interpolateGap(barMap map[time.Time]*aggregator.HourlyBar, gapStart time.Time, gapSize int, countFill GapFill) []*aggregator.HourlyBar {
 	prevTime := gapStart.Add(-time.Hour)
 	nextTime := gapStart.Add(time.Duration(gapSize) * time.Hour)
 
@@ -199,43 +273,23 @@ func interpolateGap(barMap map[time.Time]*aggregator.HourlyBar, gapStart time.Ti
 	result := make([]*aggregator.HourlyBar, gapSize)
 	for i := 0; i < gapSize; i++ {
 		weight := float64(i+1) / float64(gapSize+1)
-		result[i] = interpolateBars(prevBar, nextBar, weight)
+		result[i] = interpolateBars(prevBar, nextBar, weight, countFill)
 	}
 
 	return result
 }
 
-// interpolateBars creates an interpolated bar between two bars
-func interpolateBars(prev, next *aggregator.HourlyBar, weight float64) *aggregator.HourlyBar {
-	lerp := func(a, b float64) float64 {
-		return a + weight*(b-a)
-	}
-	lerpInt := func(a, b int64) int64 {
-		return int64(lerp(float64(a), float64(b)))
-	}
-
+// interpolateBars creates an interpolated bar between two bars, covering
+// every column in the registry. With GapFillZero, integer (count) columns
+// are left at zero instead of interpolated.
+func interpolateBars(prev, next *aggregator.HourlyBar, weight float64, countFill GapFill) *aggregator.HourlyBar {
 	bar := aggregator.NewHourlyBar()
-	bar.BuyVol = lerp(prev.BuyVol, next.BuyVol)
-	bar.SellVol = lerp(prev.SellVol, next.SellVol)
-	bar.NTrades = lerpInt(prev.NTrades, next.NTrades)
-	bar.BuyCount = lerpInt(prev.BuyCount, next.BuyCount)
-	bar.SellCount = lerpInt(prev.SellCount, next.SellCount)
-	bar.WhaleBuyVolP99 = lerp(prev.WhaleBuyVolP99, next.WhaleBuyVolP99)
-	bar.WhaleSellVolP99 = lerp(prev.WhaleSellVolP99, next.WhaleSellVolP99)
-	bar.WhaleBuyCountP99 = lerpInt(prev.WhaleBuyCountP99, next.WhaleBuyCountP99)
-	bar.WhaleSellCountP99 = lerpInt(prev.WhaleSellCountP99, next.WhaleSellCountP99)
-	bar.WhaleBuyVolP999 = lerp(prev.WhaleBuyVolP999, next.WhaleBuyVolP999)
-	bar.WhaleSellVolP999 = lerp(prev.WhaleSellVolP999, next.WhaleSellVolP999)
-	bar.WhaleBuyCountP999 = lerpInt(prev.WhaleBuyCountP999, next.WhaleBuyCountP999)
-	bar.WhaleSellCountP999 = lerpInt(prev.WhaleSellCountP999, next.WhaleSellCountP999)
-	bar.VolFirst30Min = lerp(prev.VolFirst30Min, next.VolFirst30Min)
-	bar.VolLast30Min = lerp(prev.VolLast30Min, next.VolLast30Min)
-	bar.BuyVolUSD = lerp(prev.BuyVolUSD, next.BuyVolUSD)
-	bar.SellVolUSD = lerp(prev.SellVolUSD, next.SellVolUSD)
-	bar.MaxTradeSize = lerp(prev.MaxTradeSize, next.MaxTradeSize)
-	bar.VWAP = lerp(prev.VWAP, next.VWAP)
-	bar.PriceStd = lerp(prev.PriceStd, next.PriceStd)
-
+	for _, col := range aggregator.Columns {
+		if countFill == GapFillZero && col.Type == aggregator.ColumnInt64 {
+			continue // NewHourlyBar leaves counts at zero
+		}
+		col.Lerp(bar, prev, next, weight)
+	}
 	return bar
 }
 
@@ -253,6 +307,21 @@ func TrimWarmup(bars []aggregator.HourlyResult, warmupHours int) []aggregator.Ho
 	return bars[warmupHours:]
 }
 
+// TrimBefore removes all bars earlier than cutoff. Used by lookback runs
+// to drop the extra history that only warmed the whale window.
+func TrimBefore(bars []aggregator.HourlyResult, cutoff time.Time) []aggregator.HourlyResult {
+	sort.Slice(bars, func(i, j int) bool {
+		return bars[i].Time.Before(bars[j].Time)
+	})
+
+	for i, bar := range bars {
+		if !bar.Time.Before(cutoff) {
+			return bars[i:]
+		}
+	}
+	return nil
+}
+
 // Summary prints a summary of the data
 func Summary(bars []aggregator.HourlyResult) string {
 	if len(bars) == 0 {
@@ -0,0 +1,123 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/parquet-go/parquet-go"
+)
+
+// WriteWide writes multiple symbols' hourly series as one wide-format
+// parquet file aligned on a shared hourly index: one row per hour, columns
+// prefixed per symbol (btcusdt_buy_vol, ethusdt_buy_vol, ...). Hours a
+// symbol has no bar for are null in that symbol's columns, so every column
+// is optional. Written atomically like WriteParquetColumns.
+func WriteWide(series map[string][]aggregator.HourlyResult, outputPath string, columns []string) (err error) {
+	symbols := make([]string, 0, len(series))
+	for symbol := range series {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	// Shared hourly index: the union of all symbols' hours
+	hourSet := make(map[time.Time]bool)
+	for _, bars := range series {
+		for _, bar := range bars {
+			hourSet[bar.Time] = true
+		}
+	}
+	hours := make([]time.Time, 0, len(hourSet))
+	for hour := range hourSet {
+		hours = append(hours, hour)
+	}
+	sort.Slice(hours, func(i, j int) bool { return hours[i].Before(hours[j]) })
+
+	// Schema: time plus <symbol>_<column> for every symbol and column
+	group := parquet.Group{
+		"time": parquet.Timestamp(parquet.Millisecond),
+	}
+	for _, symbol := range symbols {
+		prefix := strings.ToLower(symbol) + "_"
+		for _, name := range columns {
+			col, _ := aggregator.ColumnByName(name)
+			node := columnNode(col)
+			if !col.Nullable {
+				node = parquet.Optional(node)
+			}
+			group[prefix+name] = node
+		}
+	}
+	schema := parquet.NewSchema("hourly_wide", group)
+
+	// Index each symbol's bars by hour
+	byHour := make(map[string]map[time.Time]*aggregator.HourlyBar, len(symbols))
+	for symbol, bars := range series {
+		m := make(map[time.Time]*aggregator.HourlyBar, len(bars))
+		for _, bar := range bars {
+			m[bar.Time] = bar.Bar
+		}
+		byHour[symbol] = m
+	}
+
+	tmpPath := outputPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("create parquet file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	writer := parquet.NewGenericWriter[map[string]any](file, schema)
+
+	const batchSize = 1000
+	rows := make([]map[string]any, 0, batchSize)
+	for _, hour := range hours {
+		row := make(map[string]any, len(symbols)*len(columns)+1)
+		row["time"] = hour.UTC().UnixMilli()
+		for _, symbol := range symbols {
+			bar, ok := byHour[symbol][hour]
+			if !ok {
+				continue // null columns for this symbol
+			}
+			prefix := strings.ToLower(symbol) + "_"
+			for _, name := range columns {
+				col, _ := aggregator.ColumnByName(name)
+				row[prefix+name] = col.Value(bar)
+			}
+		}
+		rows = append(rows, row)
+
+		if len(rows) == batchSize {
+			if _, err = writer.Write(rows); err != nil {
+				return fmt.Errorf("write rows: %w", err)
+			}
+			rows = rows[:0]
+		}
+	}
+	if len(rows) > 0 {
+		if _, err = writer.Write(rows); err != nil {
+			return fmt.Errorf("write rows: %w", err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("close writer: %w", err)
+	}
+	if err = file.Sync(); err != nil {
+		return fmt.Errorf("sync parquet file: %w", err)
+	}
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("close parquet file: %w", err)
+	}
+
+	err = os.Rename(tmpPath, outputPath)
+	return err
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -35,6 +36,11 @@ type TradeCallback func(trades []Trade) error
 // ParseCSV reads a CSV file and calls the callback for each batch of trades
 // Uses streaming to minimize memory usage
 func ParseCSV(csvPath string, callback TradeCallback) (int64, error) {
+	return parseCSVBufio(csvPath, Options{}, callback)
+}
+
+// parseCSVBufio is the bufio-backed parse loop behind ParseCSV
+func parseCSVBufio(csvPath string, opts Options, callback TradeCallback) (int64, error) {
 	file, err := os.Open(csvPath)
 	if err != nil {
 		return 0, fmt.Errorf("open file: %w", err)
@@ -42,7 +48,8 @@ func ParseCSV(csvPath string, callback TradeCallback) (int64, error) {
 	defer file.Close()
 
 	reader := bufio.NewReaderSize(file, readBufferSize)
-	batch := make([]Trade, 0, batchSize)
+	maxBatch := opts.batchSize()
+	batch := make([]Trade, 0, maxBatch)
 	var totalTrades int64
 
 	// Read first line - Binance CSV may or may not have a header
@@ -55,7 +62,7 @@ func ParseCSV(csvPath string, callback TradeCallback) (int64, error) {
 	// Check if first line is header or data (Binance CSVs typically have no header)
 	if !isHeaderLine(firstLine) {
 		// First line is data, process it
-		trade, parseErr := parseLine(firstLine)
+		trade, parseErr := parseLineSkip(firstLine, opts.SkipIDs)
 		if parseErr == nil {
 			batch = append(batch, trade)
 			totalTrades++
@@ -69,13 +76,13 @@ func ParseCSV(csvPath string, callback TradeCallback) (int64, error) {
 
 		// Process line if non-empty (even on EOF with partial line)
 		if line != "" {
-			trade, parseErr := parseLine(line)
+			trade, parseErr := parseLineSkip(line, opts.SkipIDs)
 			if parseErr == nil {
 				batch = append(batch, trade)
 				totalTrades++
 
 				// Yield batch when full
-				if len(batch) >= batchSize {
+				if len(batch) >= maxBatch {
 					if cbErr := callback(batch); cbErr != nil {
 						return totalTrades, cbErr
 					}
@@ -102,40 +109,116 @@ func ParseCSV(csvPath string, callback TradeCallback) (int64, error) {
 
 // parseLine parses a single CSV line into a Trade
 func parseLine(line string) (Trade, error) {
+	return parseLineSkip(line, false)
+}
+
+// parseLineSkip parses a CSV line by scanning for commas instead of
+// splitting, so no per-line slice is allocated. With skipIDs the
+// first/last trade ID fields are left untokenized: they only feed
+// avg_fills_per_aggtrade, and the zero values make Fills() report 1.
+func parseLineSkip(line string, skipIDs bool) (Trade, error) {
 	var raw RawTrade
 	var err error
 
-	parts := strings.Split(line, ",")
-	if len(parts) < numCols-1 {
-		return Trade{}, fmt.Errorf("insufficient columns: %d", len(parts))
+	// Record the bounds of each field in one pass over the line
+	var starts, ends [numCols]int
+	fields := 0
+	start := 0
+	for i := 0; i <= len(line); i++ {
+		if i == len(line) || line[i] == ',' {
+			if fields < numCols {
+				starts[fields], ends[fields] = start, i
+				fields++
+			}
+			start = i + 1
+		}
 	}
+	if fields < numCols-1 {
+		return Trade{}, fmt.Errorf("insufficient columns: %d", fields)
+	}
+	field := func(col int) string { return line[starts[col]:ends[col]] }
 
-	raw.AggID, err = strconv.ParseInt(parts[colAggID], 10, 64)
+	raw.AggID, err = strconv.ParseInt(field(colAggID), 10, 64)
 	if err != nil {
 		return Trade{}, fmt.Errorf("parse agg_id: %w", err)
 	}
 
-	raw.Price, err = strconv.ParseFloat(parts[colPrice], 64)
+	raw.Price, err = parseFloatFast(field(colPrice))
 	if err != nil {
 		return Trade{}, fmt.Errorf("parse price: %w", err)
 	}
 
-	raw.Qty, err = strconv.ParseFloat(parts[colQty], 64)
+	raw.Qty, err = parseFloatFast(field(colQty))
 	if err != nil {
 		return Trade{}, fmt.Errorf("parse qty: %w", err)
 	}
 
-	raw.Timestamp, err = strconv.ParseInt(parts[colTime], 10, 64)
+	if !skipIDs {
+		raw.FirstID, err = strconv.ParseInt(field(colFirstID), 10, 64)
+		if err != nil {
+			return Trade{}, fmt.Errorf("parse first_id: %w", err)
+		}
+
+		raw.LastID, err = strconv.ParseInt(field(colLastID), 10, 64)
+		if err != nil {
+			return Trade{}, fmt.Errorf("parse last_id: %w", err)
+		}
+	}
+
+	raw.Timestamp, err = strconv.ParseInt(field(colTime), 10, 64)
 	if err != nil {
 		return Trade{}, fmt.Errorf("parse timestamp: %w", err)
 	}
 
-	isBuyerMaker := strings.ToLower(parts[colIsBuyerMaker])
+	isBuyerMaker := strings.ToLower(field(colIsBuyerMaker))
 	raw.IsBuyerMaker = isBuyerMaker == "true"
 
 	return raw.ToTrade(), nil
 }
 
+// AppendFile appends one CSV file to an open destination, dropping a
+// leading header line and guaranteeing a newline at the seam, so daily
+// files can be concatenated into a month the parsers treat as one CSV
+func AppendFile(dst *os.File, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return nil
+	}
+
+	reader := bufio.NewReaderSize(file, readBufferSize)
+	firstLine, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !isHeaderLine(strings.TrimSpace(firstLine)) {
+		if _, err := dst.WriteString(firstLine); err != nil {
+			return err
+		}
+	}
+	if _, err := io.Copy(dst, reader); err != nil {
+		return err
+	}
+
+	// Without a trailing newline the next file's first record would merge
+	// into this file's last
+	var last [1]byte
+	if _, err := file.ReadAt(last[:], info.Size()-1); err == nil && last[0] != '\n' {
+		if _, err := dst.Write([]byte{'\n'}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CountLines counts the number of lines in a file (for progress estimation)
 func CountLines(path string) (int64, error) {
 	file, err := os.Open(path)
@@ -156,6 +239,51 @@ func CountLines(path string) (int64, error) {
 	return count, scanner.Err()
 }
 
+// VerifyTail checks that the last line of a CSV file is a complete record.
+// A truncated download or extraction typically leaves a partial final line
+// that would otherwise be dropped silently.
+func VerifyTail(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("empty file")
+	}
+
+	// Read the last chunk of the file
+	const tailSize = 4096
+	offset := info.Size() - tailSize
+	if offset < 0 {
+		offset = 0
+	}
+	buf := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(buf, offset); err != nil {
+		return fmt.Errorf("read tail: %w", err)
+	}
+
+	tail := strings.TrimRight(string(buf), "\n\r")
+	idx := strings.LastIndexByte(tail, '\n')
+	lastLine := strings.TrimSpace(tail[idx+1:])
+
+	if lastLine == "" {
+		return nil
+	}
+	if isHeaderLine(lastLine) {
+		return nil
+	}
+	if _, err := parseLine(lastLine); err != nil {
+		return fmt.Errorf("incomplete last line %q: %w", lastLine, err)
+	}
+	return nil
+}
+
 // isHeaderLine checks if a line is a CSV header (not data)
 // Binance CSVs typically have no header, but some may have one
 func isHeaderLine(line string) bool {
@@ -0,0 +1,275 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter evaluates a boolean expression against trades, e.g.
+// "qty >= 0.001 && price > 0". Supported fields: qty, price, usd
+// (price*qty), is_buy, is_sell. Operators: comparison, &&, ||, !, parens.
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// CompileFilter parses a filter expression
+func CompileFilter(expr string) (*Filter, error) {
+	p := &filterParser{tokens: tokenizeFilter(expr)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("parse filter %q: %w", expr, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("parse filter %q: unexpected %q", expr, p.tokens[p.pos])
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match returns true if the trade passes the filter
+func (f *Filter) Match(t *Trade) bool {
+	return f.root.eval(t) != 0
+}
+
+// String returns the original expression
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// filterNode evaluates to a float64; booleans are 0/1
+type filterNode interface {
+	eval(t *Trade) float64
+}
+
+type numberNode float64
+
+func (n numberNode) eval(*Trade) float64 { return float64(n) }
+
+type fieldNode string
+
+func (f fieldNode) eval(t *Trade) float64 {
+	switch string(f) {
+	case "qty":
+		return t.Qty
+	case "price":
+		return t.Price
+	case "usd", "notional":
+		return t.Price * t.Qty
+	case "is_buy":
+		if t.IsBuy() {
+			return 1
+		}
+		return 0
+	case "is_sell":
+		if t.IsSell() {
+			return 1
+		}
+		return 0
+	}
+	return 0
+}
+
+type binaryNode struct {
+	op          string
+	left, right filterNode
+}
+
+func (b *binaryNode) eval(t *Trade) float64 {
+	l := b.left.eval(t)
+
+	// Short-circuit logical operators
+	switch b.op {
+	case "&&":
+		if l == 0 {
+			return 0
+		}
+		return boolToFloat(b.right.eval(t) != 0)
+	case "||":
+		if l != 0 {
+			return 1
+		}
+		return boolToFloat(b.right.eval(t) != 0)
+	}
+
+	r := b.right.eval(t)
+	switch b.op {
+	case ">=":
+		return boolToFloat(l >= r)
+	case "<=":
+		return boolToFloat(l <= r)
+	case ">":
+		return boolToFloat(l > r)
+	case "<":
+		return boolToFloat(l < r)
+	case "==":
+		return boolToFloat(l == r)
+	case "!=":
+		return boolToFloat(l != r)
+	}
+	return 0
+}
+
+type notNode struct {
+	inner filterNode
+}
+
+func (n *notNode) eval(t *Trade) float64 {
+	return boolToFloat(n.inner.eval(t) == 0)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// tokenizeFilter splits an expression into tokens
+func tokenizeFilter(expr string) []string {
+	var tokens []string
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case strings.ContainsRune("()", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '&' || c == '|':
+			if i+1 < len(expr) && expr[i+1] == c {
+				tokens = append(tokens, expr[i:i+2])
+				i += 2
+			} else {
+				tokens = append(tokens, string(c))
+				i++
+			}
+		case strings.ContainsRune("<>=!", rune(c)):
+			if i+1 < len(expr) && expr[i+1] == '=' {
+				tokens = append(tokens, expr[i:i+2])
+				i += 2
+			} else {
+				tokens = append(tokens, string(c))
+				i++
+			}
+		default:
+			j := i
+			for j < len(expr) && (isIdentChar(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			if j == i {
+				// Unknown character, emit as its own token so parsing fails loudly
+				j = i + 1
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// filterParser is a recursive-descent parser over tokens
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	switch op := p.peek(); op {
+	case ">=", "<=", ">", "<", "==", "!=":
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	tok := p.next()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return inner, nil
+	case tok == "!":
+		inner, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner: inner}, nil
+	default:
+		if v, err := strconv.ParseFloat(tok, 64); err == nil {
+			return numberNode(v), nil
+		}
+		switch tok {
+		case "qty", "price", "usd", "notional", "is_buy", "is_sell":
+			return fieldNode(tok), nil
+		}
+		return nil, fmt.Errorf("unknown field or token %q", tok)
+	}
+}
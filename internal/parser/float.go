@@ -0,0 +1,57 @@
+package parser
+
+import "strconv"
+
+// pow10 holds the powers of ten that are exactly representable in a
+// float64 (10^0 through 10^22)
+var pow10 = [...]float64{
+	1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10,
+	1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19, 1e20, 1e21, 1e22,
+}
+
+// parseFloatFast parses a plain decimal number — digits with at most one
+// dot and an optional leading minus — via the exact small-decimal fast
+// path: when the mantissa fits in 53 bits and the decimal exponent is at
+// most 22, float64(mantissa) / 10^exp is correctly rounded. That covers
+// every price and quantity Binance emits (fixed-point, 8 decimals) and
+// skips strconv's general-case machinery, which dominates the parse
+// profile. Anything irregular falls back to strconv.ParseFloat.
+func parseFloatFast(s string) (float64, error) {
+	i := 0
+	neg := false
+	if len(s) > 0 && s[0] == '-' {
+		neg = true
+		i = 1
+	}
+
+	var mant uint64
+	var digits, frac int
+	sawDot := false
+	for ; i < len(s); i++ {
+		c := s[i]
+		if c == '.' {
+			if sawDot {
+				return strconv.ParseFloat(s, 64)
+			}
+			sawDot = true
+			continue
+		}
+		if c < '0' || c > '9' {
+			return strconv.ParseFloat(s, 64)
+		}
+		mant = mant*10 + uint64(c-'0')
+		digits++
+		if sawDot {
+			frac++
+		}
+	}
+	if digits == 0 || digits > 19 || mant >= 1<<53 || frac > 22 {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	f := float64(mant) / pow10[frac]
+	if neg {
+		f = -f
+	}
+	return f, nil
+}
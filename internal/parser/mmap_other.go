@@ -0,0 +1,10 @@
+//go:build !unix
+
+package parser
+
+import "fmt"
+
+// mmapFile is unavailable on this platform; use the bufio backend
+func mmapFile(path string) ([]byte, func(), error) {
+	return nil, nil, fmt.Errorf("parse backend %s is not supported on this platform", BackendMmap)
+}
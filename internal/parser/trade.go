@@ -7,6 +7,9 @@ type Trade struct {
 	Time         time.Time
 	Price        float64
 	Qty          float64
+	AggID        int64
+	FirstID      int64
+	LastID       int64
 	IsBuyerMaker bool
 }
 
@@ -37,6 +40,9 @@ func (r *RawTrade) ToTrade() Trade {
 		Time:         ts,
 		Price:        r.Price,
 		Qty:          r.Qty,
+		AggID:        r.AggID,
+		FirstID:      r.FirstID,
+		LastID:       r.LastID,
 		IsBuyerMaker: r.IsBuyerMaker,
 	}
 }
@@ -66,6 +72,14 @@ func (t *Trade) Date() string {
 	return t.Time.Format("2006-01-02")
 }
 
+// Fills returns how many individual trades this aggregate covers
+func (t *Trade) Fills() int64 {
+	if t.LastID < t.FirstID {
+		return 1
+	}
+	return t.LastID - t.FirstID + 1
+}
+
 // Minute returns the minute within the hour (0-59)
 func (t *Trade) Minute() int {
 	return t.Time.Minute()
@@ -0,0 +1,118 @@
+package pipeline
+
+import (
+	"sync"
+)
+
+// control gates the download workers so an operator can pause/resume work
+// and adjust the effective worker count while the pipeline is running.
+type control struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	paused bool
+	limit  int // max concurrently active download workers
+	active int
+}
+
+func newControl(limit int) *control {
+	c := &control{limit: limit}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// acquire blocks until the pipeline is unpaused and a worker slot is free,
+// or done is closed. Returns false if cancelled while waiting.
+func (c *control) acquire(done <-chan struct{}) bool {
+	// Wake waiters when the pipeline is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-done:
+			c.cond.Broadcast()
+		case <-stop:
+		}
+	}()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.paused || c.active >= c.limit {
+		select {
+		case <-done:
+			return false
+		default:
+		}
+		c.cond.Wait()
+	}
+
+	select {
+	case <-done:
+		return false
+	default:
+	}
+	c.active++
+	return true
+}
+
+// release frees a worker slot
+func (c *control) release() {
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// Pause suspends download workers before their next month. Months already
+// in flight finish normally.
+func (p *Pipeline) Pause() {
+	p.ctl.mu.Lock()
+	p.ctl.paused = true
+	p.ctl.mu.Unlock()
+}
+
+// Resume lets paused download workers continue
+func (p *Pipeline) Resume() {
+	p.ctl.mu.Lock()
+	p.ctl.paused = false
+	p.ctl.mu.Unlock()
+	p.ctl.cond.Broadcast()
+}
+
+// SetDownloadWorkers adjusts how many download workers may be active at
+// once, between 1 and the count the pipeline was started with
+func (p *Pipeline) SetDownloadWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > p.cfg.DownloadWorkers {
+		n = p.cfg.DownloadWorkers
+	}
+	p.ctl.mu.Lock()
+	p.ctl.limit = n
+	p.ctl.mu.Unlock()
+	p.ctl.cond.Broadcast()
+}
+
+// ControlStatus is a snapshot of the runtime control state
+type ControlStatus struct {
+	Paused          bool  `json:"paused"`
+	DownloadWorkers int   `json:"download_workers"`
+	ActiveDownloads int   `json:"active_downloads"`
+	MemoryUsedBytes int64 `json:"memory_used_bytes"`
+	MemoryMaxBytes  int64 `json:"memory_max_bytes"`
+}
+
+// Status reports the current control state and memory usage
+func (p *Pipeline) Status() ControlStatus {
+	stats := p.memMgr.GetStats()
+
+	p.ctl.mu.Lock()
+	defer p.ctl.mu.Unlock()
+	return ControlStatus{
+		Paused:          p.ctl.paused,
+		DownloadWorkers: p.ctl.limit,
+		ActiveDownloads: p.ctl.active,
+		MemoryUsedBytes: stats.ActualUsed,
+		MemoryMaxBytes:  stats.MaxBytes,
+	}
+}
@@ -2,16 +2,25 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/clement/aggtrades/clock"
 	"github.com/clement/aggtrades/internal/aggregator"
+	"github.com/clement/aggtrades/internal/alert"
+	"github.com/clement/aggtrades/internal/binance"
 	"github.com/clement/aggtrades/internal/config"
 	"github.com/clement/aggtrades/internal/downloader"
+	"github.com/clement/aggtrades/internal/events"
 	"github.com/clement/aggtrades/internal/memory"
 	"github.com/clement/aggtrades/internal/parser"
 	"github.com/clement/aggtrades/internal/state"
@@ -60,21 +69,127 @@ type Pipeline struct {
 
 	// Ordered list of months for chronological processing
 	monthOrder []string
+
+	// Injectable clock (defaults to the real clock)
+	clock clock.Clock
+
+	// Runtime control (pause/resume, live worker count)
+	ctl *control
+
+	// Calibrated per-month memory estimator
+	estimator *memory.Estimator
+
+	// Per-phase time accumulation for the bottleneck report
+	times phaseTimes
+
+	// Corruption occurrences per mirror host
+	corruptMu     sync.Mutex
+	corruptCounts map[string]int
+
+	// Checksums of the source ZIPs downloaded this run
+	sourceMu     sync.Mutex
+	sourceHashes map[string]state.SourceInfo
+
+	// Months assembled from daily files this run (checkpointed as partial
+	// rather than completed), and months a previous run marked partial
+	// (their cached CSVs are stale and must be re-fetched)
+	partialMu     sync.Mutex
+	partialMonths map[string]bool
+	resumePartial map[string]bool
+
+	// Append-only provenance journal of every network download
+	journal *state.Journal
+
+	// Pinned archive checksums and the first violation observed
+	lock    *state.Lockfile
+	lockMu  sync.Mutex
+	lockErr error
+
+	// Bytes actually fetched over the network this run, per month and total
+	// (retries and ranged completions included)
+	bwMu       sync.Mutex
+	monthBytes map[string]int64
+	totalBytes int64
+
+	// CSV line counts of months extracted this run, the baseline for the
+	// silent-partial-file plausibility check
+	lineMu     sync.Mutex
+	monthLines map[string]int64
+
+	// Observed ZIP and extracted CSV bytes, for the per-symbol
+	// decompression ratio used in disk preflight checks
+	ratioMu       sync.Mutex
+	ratioZipBytes int64
+	ratioCSVBytes int64
+	seedRatio     float64
+
+	// Optional alert rules evaluated as bars finalize
+	alerts *alert.Engine
+
+	// Optional event publisher for finalized bars
+	events *events.Publisher
+
+	// Optional per-trade filter
+	filter        *parser.Filter
+	filteredCount atomic.Int64
+	keptCount     atomic.Int64
 }
 
 // NewPipeline creates a new processing pipeline
 func NewPipeline(cfg *config.Config, memMgr *memory.Manager, tui *ui.UI) *Pipeline {
 	ctx, cancel := context.WithCancel(context.Background())
-	stateDir := filepath.Join(cfg.TempDir, "checkpoint")
+	stateDir := cfg.CheckpointDir()
+
+	agg := aggregator.NewAggregator(config.PercentileWindowDays, config.ReservoirSamplesPerDay)
+	if cfg.TopKTrades > 0 {
+		agg.EnableTopTrades(cfg.TopKTrades)
+	}
+	if cfg.WhaleRanks {
+		agg.EnableWhaleRanks()
+	}
+	if cfg.StitchOrders {
+		agg.EnableStitching()
+	}
+	if cfg.PreciseSums {
+		agg.EnablePreciseSums()
+	}
+	if cfg.FlushAfterHours > 0 {
+		agg.SetFlushAfter(cfg.FlushAfterHours)
+	}
+	if len(cfg.WhaleTiers) > 0 {
+		agg.SetWhaleTiers(aggregator.RegisterWhaleTiers(cfg.WhaleTiers))
+	}
+	if cfg.CompareWindowDays > 0 {
+		agg.SetCompareDetector(cfg.CompareWindowDays, config.ReservoirSamplesPerDay)
+	}
+	if cfg.WhaleNotional {
+		agg.EnableNotionalWhales()
+	}
+	if policy, perr := aggregator.ParseLatePolicy(cfg.LatePolicy); perr == nil {
+		agg.SetLatePolicy(policy)
+	}
+	if cfg.LateGraceHours > 0 {
+		agg.SetFlushAfter(cfg.LateGraceHours)
+	}
 
 	return &Pipeline{
 		cfg:           cfg,
 		memMgr:        memMgr,
 		ui:            tui,
-		aggregator:    aggregator.NewAggregator(config.PercentileWindowDays, config.ReservoirSamplesPerDay),
+		clock:         clock.New(),
+		ctl:           newControl(cfg.DownloadWorkers),
+		estimator:     memory.NewEstimator(0),
+		corruptCounts: make(map[string]int),
+		sourceHashes:  make(map[string]state.SourceInfo),
+		partialMonths: make(map[string]bool),
+		resumePartial: make(map[string]bool),
+		monthBytes:    make(map[string]int64),
+		monthLines:    make(map[string]int64),
+		aggregator:    agg,
 		stateMgr:      state.NewManager(stateDir),
+		journal:       state.NewJournal(stateDir),
 		downloadQueue: make(chan string, 100),
-		processQueue:  make(chan Job, 10),
+		processQueue:  make(chan Job, 1),
 		resultsChan:   make(chan Result, 100),
 		errorsChan:    make(chan error, 10),
 		failedMonths:  make(chan string, 100),
@@ -83,8 +198,159 @@ func NewPipeline(cfg *config.Config, memMgr *memory.Manager, tui *ui.UI) *Pipeli
 	}
 }
 
+// Sources returns the recorded source ZIP checksums per month, including
+// those carried over from previous runs via the checkpoint. Only valid
+// after Run.
+func (p *Pipeline) Sources() map[string]state.SourceInfo {
+	if p.state == nil {
+		return nil
+	}
+	return p.state.Sources
+}
+
+// recordDownload accounts bytes fetched over the network for a month and
+// enforces the optional total-download safety cap
+func (p *Pipeline) recordDownload(month string, bytes int64) error {
+	p.bwMu.Lock()
+	p.monthBytes[month] += bytes
+	p.totalBytes += bytes
+	total := p.totalBytes
+	p.bwMu.Unlock()
+
+	if limit := p.cfg.MaxTotalDownloadBytes; limit > 0 && total > limit {
+		return fmt.Errorf("total download %.1f GB exceeds --max-total-download %.1f GB",
+			float64(total)/1024/1024/1024, float64(limit)/1024/1024/1024)
+	}
+	return nil
+}
+
+// observeDecompression records one month's ZIP and extracted CSV sizes,
+// refining the per-symbol decompression ratio
+func (p *Pipeline) observeDecompression(zipBytes, csvBytes int64) {
+	if zipBytes <= 0 || csvBytes <= 0 {
+		return
+	}
+	p.ratioMu.Lock()
+	p.ratioZipBytes += zipBytes
+	p.ratioCSVBytes += csvBytes
+	p.ratioMu.Unlock()
+}
+
+// decompressRatio returns the CSV-to-ZIP size ratio: observed this run if
+// available, else the ratio persisted from previous runs, else the generic
+// default
+func (p *Pipeline) decompressRatio() float64 {
+	p.ratioMu.Lock()
+	defer p.ratioMu.Unlock()
+
+	if p.ratioZipBytes > 0 {
+		return float64(p.ratioCSVBytes) / float64(p.ratioZipBytes)
+	}
+	if p.seedRatio > 0 {
+		return p.seedRatio
+	}
+	return config.DecompressionRatio
+}
+
+// StitchStats reports logical-order stitching results. Only meaningful
+// when stitching is enabled.
+func (p *Pipeline) StitchStats() (orders, upgradedP99, upgradedP999 int64) {
+	return p.aggregator.StitchStats()
+}
+
+// LateStats reports how many late trades were dropped and how many bar
+// corrections were published under the late-data policy
+func (p *Pipeline) LateStats() (dropped, corrections int64) {
+	return p.aggregator.LateStats()
+}
+
+// TopUpFromREST extends a finished batch run to near-real-time: it fetches
+// the trades between the hour after lastBar and the most recent complete
+// hour from the REST aggTrades endpoint (the dump files lag by about a
+// day), runs them through the same aggregation path, and returns the new
+// hours with their source_rest column set. Returns the trade count fetched.
+func (p *Pipeline) TopUpFromREST(lastBar time.Time) ([]aggregator.HourlyResult, int, error) {
+	from := lastBar.Add(time.Hour)
+	to := p.clock.Now().UTC().Truncate(time.Hour)
+	if !from.Before(to) {
+		return nil, 0, nil
+	}
+
+	trades, err := binance.FetchAggTrades(p.ctx, p.cfg.Symbol, from, to)
+	if err != nil {
+		return nil, 0, fmt.Errorf("REST top-up: %w", err)
+	}
+	fetched := len(trades)
+	trades = p.applyFilter(trades)
+	if len(trades) == 0 {
+		return nil, fetched, nil
+	}
+
+	// The detector still holds the run's rolling window, so classification
+	// matches what the dump files would have produced
+	p.aggregator.ProcessTrades(trades)
+
+	bars := p.aggregator.GetAllBars()
+	result := make([]aggregator.HourlyResult, 0, len(bars))
+	for _, hr := range bars {
+		if hr.Time.Before(from) {
+			continue
+		}
+		hr.Bar.SourceRest = 1
+		hr.Bar.Source = aggregator.SourceREST
+		result = append(result, hr)
+	}
+	return result, fetched, nil
+}
+
+// MaxSumDrift reports the largest relative drift between naive and
+// compensated volume sums. Only meaningful when precise sums are enabled.
+func (p *Pipeline) MaxSumDrift() float64 {
+	return p.aggregator.MaxSumDrift()
+}
+
+// Downloaded returns the bytes fetched over the network this run, in total
+// and per month
+func (p *Pipeline) Downloaded() (total int64, perMonth map[string]int64) {
+	p.bwMu.Lock()
+	defer p.bwMu.Unlock()
+
+	perMonth = make(map[string]int64, len(p.monthBytes))
+	for month, bytes := range p.monthBytes {
+		perMonth[month] = bytes
+	}
+	return p.totalBytes, perMonth
+}
+
+// SetLockfile attaches pinned archive checksums; every downloaded month
+// must then match its pin or the run fails. Call before Run.
+func (p *Pipeline) SetLockfile(lf *state.Lockfile) {
+	p.lock = lf
+}
+
+// SetAlerts installs an alert engine whose rules are evaluated against each
+// bar as it finalizes at checkpoint time. Call before Run.
+func (p *Pipeline) SetAlerts(e *alert.Engine) {
+	p.alerts = e
+}
+
+// SetPublisher installs an event publisher that receives each bar as it
+// finalizes at checkpoint time. Call before Run.
+func (p *Pipeline) SetPublisher(pub *events.Publisher) {
+	p.events = pub
+}
+
 // Run executes the pipeline
 func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
+	// Compile the trade filter if one is configured
+	if p.cfg.Filter != "" {
+		filter, err := parser.CompileFilter(p.cfg.Filter)
+		if err != nil {
+			return nil, err
+		}
+		p.filter = filter
+	}
+
 	// Load existing state for resume
 	var err error
 	p.state, err = p.stateMgr.Load()
@@ -99,6 +365,34 @@ func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
 		}
 	}
 
+	// Refuse to mix bars computed under different settings
+	fingerprint := p.cfg.Fingerprint()
+	if p.state.Fingerprint != "" && p.state.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("checkpoint was created with incompatible settings (%s, current %s); clear %s to start fresh",
+			p.state.Fingerprint, fingerprint, p.stateMgr.Dir())
+	}
+	p.state.Fingerprint = fingerprint
+
+	// Seed the memory estimator with the ratio learned in previous runs
+	if p.state.TradesPerByte > 0 {
+		p.estimator = memory.NewEstimator(p.state.TradesPerByte)
+	}
+
+	// Seed the decompression ratio observed in previous runs
+	if p.state.DecompressRatio > 0 {
+		p.ratioMu.Lock()
+		p.seedRatio = p.state.DecompressRatio
+		p.ratioMu.Unlock()
+	}
+
+	// Snapshot months a previous run assembled from daily files. Their
+	// cached CSVs are stale; downloadMonth discards them so the run
+	// fetches the newly published days or the monthly archive. Taken as a
+	// snapshot here so download workers don't race checkpoint updates.
+	for _, m := range p.state.PartialMonths {
+		p.resumePartial[m] = true
+	}
+
 	// Restore detector state if available
 	if len(p.state.CompletedMonths) > 0 {
 		if err := p.restoreDetectorState(); err != nil {
@@ -106,6 +400,9 @@ func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
 		}
 	}
 
+	// Clean up orphaned temp files from previous interrupted runs
+	p.cleanOrphans()
+
 	// Get all months to process
 	allMonths, err := p.cfg.GenerateMonths()
 	if err != nil {
@@ -125,14 +422,27 @@ func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
 	// Store month order for chronological processing
 	p.monthOrder = GetMonthsInOrder(pendingMonths)
 
+	// Feed known sizes of the pending months into the ETA calculation
+	if len(p.cfg.MonthSizes) > 0 {
+		pendingSizes := make(map[string]int64, len(pendingMonths))
+		for _, month := range pendingMonths {
+			if size, ok := p.cfg.MonthSizes[month]; ok {
+				pendingSizes[month] = size
+			}
+		}
+		p.ui.SetMonthSizes(pendingSizes)
+	}
+
 	// Start workers
 	p.startDownloadWorkers(p.cfg.DownloadWorkers)
 	p.startDispatcher()
 	p.startResultCollector()
 
-	// Queue months for download (in order)
+	// Queue months for download, letting large months start early within
+	// each worker window
+	downloadOrder := p.downloadOrder(p.monthOrder)
 	go func() {
-		for _, month := range pendingMonths {
+		for _, month := range downloadOrder {
 			select {
 			case <-p.ctx.Done():
 				return
@@ -151,6 +461,19 @@ func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
 	// Wait for all processing to complete
 	p.wg.Wait()
 
+	// Report how often downloads were corrupt, per mirror
+	p.corruptMu.Lock()
+	for host, count := range p.corruptCounts {
+		p.ui.LogWarning("Corruption: %d corrupt or truncated downloads from %s", count, host)
+	}
+	p.corruptMu.Unlock()
+
+	// Report filter counts
+	if p.filter != nil {
+		p.ui.LogInfo("Filter %q: %d trades kept, %d dropped",
+			p.filter, p.keptCount.Load(), p.filteredCount.Load())
+	}
+
 	// Flush aggregator
 	p.aggregator.Flush()
 
@@ -163,6 +486,15 @@ func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
 		return nil, fmt.Errorf("load completed bars: %w", err)
 	}
 
+	// A pinned-checksum violation poisons the dataset, not just the month:
+	// fail the run so nothing downstream consumes mixed inputs
+	p.lockMu.Lock()
+	lockErr := p.lockErr
+	p.lockMu.Unlock()
+	if lockErr != nil {
+		return nil, fmt.Errorf("lockfile: %w", lockErr)
+	}
+
 	// Merge and sort all bars
 	allBars := append(completedBars, newBars...)
 	sort.Slice(allBars, func(i, j int) bool {
@@ -172,6 +504,36 @@ func (p *Pipeline) Run() ([]aggregator.HourlyResult, error) {
 	return allBars, nil
 }
 
+// recordCorruption counts a failed download verification against the
+// mirror that served it
+func (p *Pipeline) recordCorruption(rawURL string) {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	p.corruptMu.Lock()
+	p.corruptCounts[host]++
+	p.corruptMu.Unlock()
+}
+
+// cleanOrphans removes stale .tmp files and artifacts of completed months
+// left behind by interrupted runs.
+func (p *Pipeline) cleanOrphans() {
+	report, err := downloader.ScanOrphans(p.cfg.TempDir, p.state.CompletedMonths)
+	if err != nil {
+		p.ui.LogWarning("Temp dir scan failed: %v", err)
+		return
+	}
+	if report.Count() == 0 {
+		return
+	}
+
+	removed := downloader.RemoveOrphans(report)
+	p.ui.LogInfo("Cleaned %d orphaned temp files (%.1f MB)",
+		removed, float64(report.TotalBytes)/1024/1024)
+}
+
 // restoreDetectorState loads and restores the detector state from disk.
 func (p *Pipeline) restoreDetectorState() error {
 	detectorState, err := state.LoadDetector(p.stateMgr.DetectorPath())
@@ -185,6 +547,28 @@ func (p *Pipeline) restoreDetectorState() error {
 	p.aggregator.Detector().ImportState(detectorState.ToWhaleReservoirs(), detectorState.DateOrder)
 	p.aggregator.Detector().ImportBootstrap(detectorState.ToWhaleBootstrap())
 	p.ui.LogInfo("Restored detector state (%d days, bootstrap=%v)", len(detectorState.DateOrder), detectorState.Bootstrap.Bootstrapped)
+
+	// The comparison detector's reservoirs are restored from their own
+	// file, so the _w<days> columns stay continuous across resumes
+	if cd := p.aggregator.CompareDetector(); cd != nil {
+		compareState, err := state.LoadDetector(p.stateMgr.ComparePath())
+		if err == nil && compareState != nil {
+			cd.ImportState(compareState.ToWhaleReservoirs(), compareState.DateOrder)
+			cd.ImportBootstrap(compareState.ToWhaleBootstrap())
+		}
+	}
+
+	// Seasonal hour-of-day volumes live alongside the detector state
+	seasonal, err := state.LoadSeasonal(p.stateMgr.SeasonalPath())
+	if err == nil && seasonal != nil {
+		p.aggregator.Seasonal().Import(seasonal)
+	}
+
+	// Lot-size regime tracker too, so regime ids stay stable across resumes
+	lot, err := state.LoadLot(p.stateMgr.LotPath())
+	if err == nil && lot != nil {
+		p.aggregator.Lot().Import(*lot)
+	}
 	return nil
 }
 
@@ -207,6 +591,17 @@ func (p *Pipeline) downloadWorker() {
 		default:
 		}
 
+		// Honor operator pause and live worker-count limits
+		if !p.ctl.acquire(p.ctx.Done()) {
+			return
+		}
+
+		// Pause while the temp directory lacks headroom for this month
+		if !p.waitForTempSpace(month) {
+			p.ctl.release()
+			return
+		}
+
 		// Notify UI
 		p.ui.WorkerChan <- ui.WorkerUpdate{
 			Type:   ui.WorkerDownload,
@@ -222,6 +617,8 @@ func (p *Pipeline) downloadWorker() {
 			Active: false,
 		}
 
+		p.ctl.release()
+
 		if err != nil {
 			p.errorsChan <- fmt.Errorf("download %s: %w", month, err)
 			p.failedMonths <- month
@@ -234,6 +631,141 @@ func (p *Pipeline) downloadWorker() {
 	}
 }
 
+// waitForTempSpace blocks while the temp directory lacks headroom for the
+// month's ZIP plus its extracted CSV (sized with the learned per-symbol
+// decompression ratio), so downloads don't fill the disk when they outpace
+// processing. Returns false if the pipeline was cancelled while waiting.
+func (p *Pipeline) waitForTempSpace(month string) bool {
+	if p.cfg.MaxTempSizeBytes <= 0 {
+		return true
+	}
+
+	// Expected footprint of this month: the ZIP and the CSV extracted
+	// from it (zero when discovery didn't run; the cap alone applies then)
+	var need int64
+	if expected, ok := p.cfg.MonthSizes[month]; ok {
+		need = expected + int64(float64(expected)*p.decompressRatio())
+	}
+
+	warned := false
+	for {
+		size, err := downloader.DirSize(p.cfg.TempDir)
+		if err != nil || size == 0 || size+need < p.cfg.MaxTempSizeBytes {
+			return true
+		}
+
+		if !warned {
+			p.ui.LogWarning("Temp dir at %.1f GB (cap %.1f GB), pausing downloads until processing frees space",
+				float64(size)/1024/1024/1024, float64(p.cfg.MaxTempSizeBytes)/1024/1024/1024)
+			warned = true
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return false
+		case <-p.clock.After(2 * time.Second):
+		}
+	}
+}
+
+// downloadOrder returns the order months are handed to download workers.
+// Processing stays strictly chronological, so the queue does too, except
+// that within each window of one-month-per-worker the largest month (by
+// listing size) starts first: the big month gets its head start while the
+// window's small months still finish early, so the sequential processor is
+// not starved behind a huge month. Without listing sizes the order is
+// unchanged. The window never exceeds the worker or prefetch count, so a
+// month is never displaced past the downloads that must complete before
+// the dispatcher can accept it.
+func (p *Pipeline) downloadOrder(months []string) []string {
+	if len(p.cfg.MonthSizes) == 0 {
+		return months
+	}
+	window := p.cfg.DownloadWorkers
+	if p.cfg.Prefetch < window {
+		window = p.cfg.Prefetch
+	}
+	if window < 2 {
+		return months
+	}
+
+	ordered := make([]string, 0, len(months))
+	for i := 0; i < len(months); i += window {
+		end := i + window
+		if end > len(months) {
+			end = len(months)
+		}
+		block := append([]string(nil), months[i:end]...)
+		sort.SliceStable(block, func(a, b int) bool {
+			return p.cfg.MonthSizes[block[a]] > p.cfg.MonthSizes[block[b]]
+		})
+		ordered = append(ordered, block...)
+	}
+	return ordered
+}
+
+// plausibleLineCount checks a freshly extracted CSV's row count against
+// the mean of the months already extracted this run. A month below 10% of
+// its neighbors is almost always a silently truncated file, not a quiet
+// market. Returns the baseline and whether the count is plausible.
+func (p *Pipeline) plausibleLineCount(lines int64) (int64, bool) {
+	p.lineMu.Lock()
+	defer p.lineMu.Unlock()
+
+	if len(p.monthLines) == 0 {
+		return 0, true
+	}
+	var sum int64
+	for _, n := range p.monthLines {
+		sum += n
+	}
+	baseline := sum / int64(len(p.monthLines))
+	return baseline, lines*10 >= baseline
+}
+
+func (p *Pipeline) recordLineCount(month string, lines int64) {
+	p.lineMu.Lock()
+	p.monthLines[month] = lines
+	p.lineMu.Unlock()
+}
+
+// deadLetter preserves a month's repeatedly failing artifacts under the
+// checkpoint directory with an error report, instead of deleting them, so
+// the bad archive can be inspected and reported upstream
+func (p *Pipeline) deadLetter(month, url string, cause error, paths ...string) {
+	dir := filepath.Join(p.stateMgr.Dir(), "deadletter", month)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		p.ui.LogWarning("Could not create dead-letter dir for %s: %v", month, err)
+		return
+	}
+
+	var kept []string
+	for _, path := range paths {
+		if exists, _ := downloader.FileExists(path); !exists {
+			continue
+		}
+		dest := filepath.Join(dir, filepath.Base(path))
+		if err := os.Rename(path, dest); err != nil {
+			p.ui.LogWarning("Could not move %s to dead-letter dir: %v", path, err)
+			continue
+		}
+		kept = append(kept, filepath.Base(path))
+	}
+
+	report := fmt.Sprintf("month: %s\ntime: %s\nurl: %s\nerror: %v\nfiles: %s\n",
+		month, time.Now().UTC().Format(time.RFC3339), url, cause, strings.Join(kept, ", "))
+	if err := os.WriteFile(filepath.Join(dir, "error.txt"), []byte(report), 0644); err != nil {
+		p.ui.LogWarning("Could not write dead-letter report for %s: %v", month, err)
+	}
+
+	p.ui.LogWarning("Moved failing artifacts for %s to %s for inspection", month, dir)
+}
+
+// simTradesPerHour is the synthetic data density in --simulate runs:
+// enough to exercise every aggregation path while keeping months cheap to
+// generate
+const simTradesPerHour = 120
+
 // downloadMonth downloads and extracts a month's data
 func (p *Pipeline) downloadMonth(month string) (*Job, error) {
 	zipPath := p.cfg.MonthZipPath(month)
@@ -243,6 +775,13 @@ func (p *Pipeline) downloadMonth(month string) (*Job, error) {
 	os.Remove(zipPath + ".tmp")
 	os.Remove(csvPath + ".tmp")
 
+	// A month a previous run assembled from daily files is partial: drop
+	// the stale assembly so this run re-fetches the newly published days,
+	// or the monthly archive once it exists
+	if p.resumePartial[month] {
+		os.Remove(csvPath)
+	}
+
 	// Check if CSV already exists (complete file)
 	if exists, size := downloader.FileExists(csvPath); exists {
 		return &Job{
@@ -252,34 +791,340 @@ func (p *Pipeline) downloadMonth(month string) (*Job, error) {
 		}, nil
 	}
 
-	// Check if ZIP already exists (complete file)
-	zipExists, _ := downloader.FileExists(zipPath)
+	// Simulation: synthesize the month locally instead of downloading, so
+	// checkpoint/resume and memory behavior can be exercised without
+	// network or disk-heavy I/O
+	if p.cfg.Simulate {
+		if err := parser.WriteSyntheticMonth(csvPath, month, simTradesPerHour); err != nil {
+			return nil, err
+		}
+		_, size := downloader.FileExists(csvPath)
+		return &Job{
+			Month:   month,
+			CSVPath: csvPath,
+			CSVSize: size,
+		}, nil
+	}
+
+	// Offline runs never download; the ZIP must already be on disk
+	if p.cfg.Offline {
+		if zipExists, _ := downloader.FileExists(zipPath); !zipExists {
+			return nil, fmt.Errorf("%s: no local ZIP or CSV in %s (offline run)", month, p.cfg.TempDir)
+		}
+	}
+
+	// Download + extract with one re-download retry: a truncated extraction
+	// or incomplete CSV tail means the ZIP itself is corrupt
+	var csvSize int64
+	var lastErr error
+	var tries int
+	var downloadedURL string
+	var downloadSecs float64
+	for attempt := 0; attempt < 2; attempt++ {
+		// Download if needed, trying known filename variants (casing,
+		// zero-padding) before declaring the month missing
+		if zipExists, _ := downloader.FileExists(zipPath); !zipExists {
+			downloadStart := time.Now()
+			var url string
+			var size int64
+			var err error
+			for i, variant := range p.cfg.MonthURLVariants(month) {
+				url = variant
+				tries++
+				size, err = downloader.DownloadWithRetry(p.ctx, variant, zipPath, nil)
+				if err == nil {
+					if i > 0 {
+						p.ui.LogInfo("Found %s under alternate name %s", month, variant)
+					}
+					break
+				}
+				if !errors.Is(err, downloader.ErrNotFound) {
+					break
+				}
+			}
+			p.times.add(&p.times.download, downloadStart)
+			if err != nil {
+				// Monthly archives lag a few days behind; a month missing
+				// upstream may still exist as daily files (the current,
+				// incomplete month). Assemble those before giving up.
+				if errors.Is(err, downloader.ErrNotFound) {
+					if job, dayErr := p.assembleMonthFromDays(month, csvPath); dayErr == nil {
+						return job, nil
+					} else if !errors.Is(dayErr, downloader.ErrNotFound) {
+						p.ui.LogWarning("Assembling %s from daily files failed: %v", month, dayErr)
+					}
+				}
+				return nil, err
+			}
+			downloadedURL = url
+			downloadSecs = time.Since(downloadStart).Seconds()
+			if err := p.recordDownload(month, size); err != nil {
+				return nil, err
+			}
+
+			// Validate against the size from the discovery listing
+			if expected, ok := p.cfg.MonthSizes[month]; ok && size != expected {
+				p.recordCorruption(url)
+
+				// A short file can be completed with a ranged request
+				// instead of re-downloading the whole month
+				if size < expected {
+					if _, rangeErr := downloader.DownloadRange(p.ctx, url, zipPath, size, expected); rangeErr == nil {
+						p.ui.LogInfo("Completed truncated download for %s with a ranged request (%d missing bytes)", month, expected-size)
+						if err := p.recordDownload(month, expected-size); err != nil {
+							return nil, err
+						}
+					} else {
+						os.Remove(zipPath)
+						lastErr = fmt.Errorf("download size mismatch for %s: got %d, expected %d bytes", month, size, expected)
+						continue
+					}
+				} else {
+					os.Remove(zipPath)
+					lastErr = fmt.Errorf("download size mismatch for %s: got %d, expected %d bytes", month, size, expected)
+					continue
+				}
+			}
+		}
+
+		// The ZIP is deleted on successful extraction, so capture its
+		// size now for the manifest, the journal, and the
+		// decompression-ratio learning after extraction
+		_, zipSize := downloader.FileExists(zipPath)
+
+		// Record the source checksum for the reproducibility manifest
+		// while the ZIP is still on disk, and journal the download for
+		// provenance audits
+		if sum, err := downloader.FileSHA256(zipPath); err == nil {
+			// A pinned month whose archive hash changed was silently
+			// replaced upstream; refuse it rather than produce bars
+			// from a different input than the published dataset
+			if p.lock != nil {
+				if want, ok := p.lock.Sources[month]; ok && want.SHA256 != sum {
+					lockErr := fmt.Errorf("%s: archive sha256 %s does not match pinned %s; upstream file was replaced", month, sum, want.SHA256)
+					p.lockMu.Lock()
+					if p.lockErr == nil {
+						p.lockErr = lockErr
+					}
+					p.lockMu.Unlock()
+					return nil, lockErr
+				}
+			}
+
+			p.sourceMu.Lock()
+			p.sourceHashes[month] = state.SourceInfo{SHA256: sum, Bytes: zipSize}
+			p.sourceMu.Unlock()
+
+			if downloadedURL != "" {
+				entry := state.JournalEntry{
+					Time:     time.Now().UTC(),
+					Month:    month,
+					URL:      downloadedURL,
+					Bytes:    zipSize,
+					SHA256:   sum,
+					Duration: downloadSecs,
+					Attempts: tries,
+				}
+				if err := p.journal.Append(entry); err != nil {
+					p.ui.LogWarning("Download journal write failed: %v", err)
+				}
+			}
+		}
 
-	// Download if needed
-	if !zipExists {
-		url := p.cfg.MonthURL(month)
-		_, err := downloader.DownloadWithRetry(p.ctx, url, zipPath, nil)
+		extractStart := time.Now()
+		size, err := p.extractMonth(month, zipPath, csvPath)
+		p.times.add(&p.times.extract, extractStart)
+		if err == nil {
+			err = parser.VerifyTail(csvPath)
+			if err != nil && attempt == 0 {
+				os.Remove(csvPath)
+			}
+		}
+		if err == nil {
+			// A structurally valid CSV with implausibly few rows is a
+			// silent partial file; re-download once before accepting it
+			if lines, cntErr := parser.CountLines(csvPath); cntErr == nil {
+				baseline, ok := p.plausibleLineCount(lines)
+				if !ok && attempt == 0 {
+					os.Remove(csvPath)
+					err = fmt.Errorf("implausibly few trades: %d rows vs ~%d in neighboring months", lines, baseline)
+				} else {
+					if !ok {
+						p.ui.LogWarning("%s still has implausibly few trades after re-download (%d rows vs ~%d); accepting as-is", month, lines, baseline)
+					}
+					p.recordLineCount(month, lines)
+				}
+			}
+		}
+		if err == nil {
+			csvSize = size
+			lastErr = nil
+			p.observeDecompression(zipSize, csvSize)
+			break
+		}
+
+		// ZIP might be corrupted; on the first failure remove it and
+		// re-download, on the second keep the artifacts for inspection
+		p.recordCorruption(p.cfg.MonthURL(month))
+		lastErr = err
+		if attempt == 0 {
+			os.Remove(zipPath)
+			p.ui.LogWarning("Corrupt archive for %s (%v), re-downloading", month, err)
+		}
+	}
+	if lastErr != nil {
+		p.deadLetter(month, downloadedURL, lastErr, zipPath, csvPath)
+		return nil, lastErr
+	}
+
+	return &Job{
+		Month:   month,
+		ZipPath: zipPath,
+		CSVPath: csvPath,
+		CSVSize: csvSize,
+	}, nil
+}
+
+// assembleMonthFromDays builds a month's CSV by concatenating its daily
+// Data Vision files. Monthly archives are published a few days after a
+// month closes, so the current month exists only as daily ZIPs; stitching
+// them lets the run cover it instead of stopping at the last monthly
+// file. The first unpublished day ends the month.
+func (p *Pipeline) assembleMonthFromDays(month, csvPath string) (*Job, error) {
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpPath := csvPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	defer os.Remove(tmpPath)
+
+	var days int
+	for day := monthStart; day.Month() == monthStart.Month(); day = day.AddDate(0, 0, 1) {
+		dayStr := day.Format("2006-01-02")
+		dayZip := fmt.Sprintf("%s/%s.zip", p.cfg.TempDir, dayStr)
+		dayCSV := fmt.Sprintf("%s/%s.csv", p.cfg.TempDir, dayStr)
+
+		downloadStart := time.Now()
+		size, err := downloader.DownloadWithRetry(p.ctx, p.cfg.DayURL(dayStr), dayZip, nil)
+		p.times.add(&p.times.download, downloadStart)
+		if errors.Is(err, downloader.ErrNotFound) {
+			break
+		}
 		if err != nil {
+			return nil, fmt.Errorf("download %s: %w", dayStr, err)
+		}
+		if err := p.recordDownload(month, size); err != nil {
 			return nil, err
 		}
+
+		// Journal each daily file like a monthly download
+		if sum, hashErr := downloader.FileSHA256(dayZip); hashErr == nil {
+			entry := state.JournalEntry{
+				Time:   time.Now().UTC(),
+				Month:  dayStr,
+				URL:    p.cfg.DayURL(dayStr),
+				Bytes:  size,
+				SHA256: sum,
+			}
+			if err := p.journal.Append(entry); err != nil {
+				p.ui.LogWarning("Download journal write failed: %v", err)
+			}
+		}
+
+		extractStart := time.Now()
+		_, err = p.extractMonth(month, dayZip, dayCSV)
+		p.times.add(&p.times.extract, extractStart)
+		if err != nil {
+			return nil, fmt.Errorf("extract %s: %w", dayStr, err)
+		}
+
+		if err := parser.AppendFile(out, dayCSV); err != nil {
+			return nil, fmt.Errorf("append %s: %w", dayStr, err)
+		}
+		os.Remove(dayCSV)
+		days++
+	}
+	if days == 0 {
+		return nil, downloader.ErrNotFound
 	}
 
-	// Extract to temp file first, then rename
-	csvSize, err := downloader.ExtractAndRemoveZip(zipPath, csvPath)
-	if err != nil {
-		// ZIP might be corrupted, remove it and retry on next run
-		os.Remove(zipPath)
+	if err := out.Sync(); err != nil {
 		return nil, err
 	}
+	if err := os.Rename(tmpPath, csvPath); err != nil {
+		return nil, err
+	}
+	if err := parser.VerifyTail(csvPath); err != nil {
+		os.Remove(csvPath)
+		return nil, err
+	}
+
+	// Record the assembled CSV in the manifest sources. A partial month
+	// grows as more days are published, so this hash is expected to
+	// change between runs and is not held against a lockfile pin.
+	_, csvSize := downloader.FileExists(csvPath)
+	if sum, hashErr := downloader.FileSHA256(csvPath); hashErr == nil {
+		p.sourceMu.Lock()
+		p.sourceHashes[month] = state.SourceInfo{SHA256: sum, Bytes: csvSize}
+		p.sourceMu.Unlock()
+	}
 
+	p.markPartialMonth(month)
+	p.ui.LogInfo("Assembled %s from %d daily files (monthly archive not yet published)", month, days)
 	return &Job{
 		Month:   month,
-		ZipPath: zipPath,
 		CSVPath: csvPath,
 		CSVSize: csvSize,
 	}, nil
 }
 
+// markPartialMonth flags a month assembled from daily files this run, so
+// its checkpoint records it as partial rather than completed
+func (p *Pipeline) markPartialMonth(month string) {
+	p.partialMu.Lock()
+	p.partialMonths[month] = true
+	p.partialMu.Unlock()
+}
+
+// isPartialMonth reports whether this run assembled the month from daily
+// files
+func (p *Pipeline) isPartialMonth(month string) bool {
+	p.partialMu.Lock()
+	defer p.partialMu.Unlock()
+	return p.partialMonths[month]
+}
+
+// extractMonth extracts a month's ZIP while reporting progress to the UI
+func (p *Pipeline) extractMonth(month, zipPath, csvPath string) (int64, error) {
+	p.ui.WorkerChan <- ui.WorkerUpdate{
+		Type:   ui.WorkerExtract,
+		Month:  month,
+		Active: true,
+	}
+	defer func() {
+		p.ui.WorkerChan <- ui.WorkerUpdate{
+			Type:  ui.WorkerExtract,
+			Month: month,
+		}
+	}()
+
+	return downloader.ExtractAndRemoveZip(p.ctx, zipPath, csvPath, func(written, total int64) {
+		p.ui.WorkerChan <- ui.WorkerUpdate{
+			Type:       ui.WorkerExtract,
+			Month:      month,
+			Active:     true,
+			BytesDown:  written,
+			BytesTotal: total,
+		}
+	})
+}
+
 // startDispatcher starts the memory-aware job dispatcher
 func (p *Pipeline) startDispatcher() {
 	p.wg.Add(1)
@@ -316,7 +1161,7 @@ func (p *Pipeline) dispatcher() {
 			}
 
 			// Next month is ready - wait for memory and process
-			estimatedMem := memory.EstimateProcessMemory(job.CSVSize)
+			estimatedMem := p.estimator.Estimate(job.CSVSize)
 			p.memMgr.Reserve(estimatedMem) // Blocking wait for memory
 
 			delete(readyJobs, nextMonth)
@@ -331,12 +1176,19 @@ func (p *Pipeline) dispatcher() {
 			return
 		}
 
+		// Only accept more downloaded jobs while under the prefetch limit,
+		// otherwise download workers block and stop accumulating months
+		incoming := p.processQueue
+		if !processQueueClosed && len(readyJobs) >= p.cfg.Prefetch {
+			incoming = nil
+		}
+
 		// Get next downloaded job or wait
 		select {
 		case <-p.ctx.Done():
 			return
 
-		case job, ok := <-p.processQueue:
+		case job, ok := <-incoming:
 			if !ok {
 				processQueueClosed = true
 				continue
@@ -347,7 +1199,7 @@ func (p *Pipeline) dispatcher() {
 		case failed := <-p.failedMonths:
 			failedSet[failed] = true
 
-		case <-time.After(50 * time.Millisecond):
+		case <-p.clock.After(50 * time.Millisecond):
 			// Timeout to check for new jobs
 		}
 	}
@@ -366,6 +1218,11 @@ func (p *Pipeline) processMonthSequential(job Job, reservedMem int64) {
 
 	result := p.processMonth(job)
 
+	// Calibrate the memory model with the observed trades-per-byte ratio
+	if result.Error == nil {
+		p.estimator.Observe(job.CSVSize, result.Trades)
+	}
+
 	// Notify UI - end
 	p.ui.WorkerChan <- ui.WorkerUpdate{
 		Type:   ui.WorkerProcess,
@@ -401,14 +1258,71 @@ func (p *Pipeline) processWorker(job Job, reservedMem int64) {
 }
 
 // processMonth processes a single month's CSV file
-func (p *Pipeline) processMonth(job Job) Result {
-	result := Result{Month: job.Month}
+// monthSkewTolerance is how far a trade's timestamp may fall outside its
+// file's month before it counts as a clock-skew violation. Exchange-side
+// clock drift of a few seconds around month boundaries is normal.
+const monthSkewTolerance = time.Minute
+
+func (p *Pipeline) processMonth(job Job) (result Result) {
+	result = Result{Month: job.Month}
+
+	// One malformed file must not take down the whole run: recover from
+	// panics in parsing or aggregation, log the stack, and report the
+	// month as failed. It stays out of CompletedMonths so a later run
+	// retries it; state is saved so progress up to here survives.
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		p.ui.LogError("Panic processing %s: %v\n%s", job.Month, r, debug.Stack())
+		result.Error = fmt.Errorf("panic processing %s: %v", job.Month, r)
+		if p.state != nil {
+			if err := p.stateMgr.Save(p.state); err != nil {
+				p.ui.LogError("Failed to save state after panic: %v", err)
+			}
+		}
+	}()
+
+	// Month bounds for the clock-skew check: every trade in the file
+	// should fall inside the month the file is named after
+	monthStart, _ := time.Parse("2006-01", job.Month)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	var skewed int64
+	var maxSkew time.Duration
+
+	// Parse CSV and aggregate, attributing callback time to the aggregate
+	// phase and the remainder of ParseCSV to parsing
+	parseStart := time.Now()
+	aggBefore := p.times.aggregate.Load()
+	opts := parser.Options{
+		Backend:   p.cfg.ParseBackend,
+		SkipIDs:   p.skipIDs(),
+		BatchSize: p.cfg.ParseBatchSize,
+	}
+	totalTrades, err := parser.ParseCSVOpts(job.CSVPath, opts, func(trades []parser.Trade) error {
+		for _, trade := range trades {
+			var skew time.Duration
+			if trade.Time.Before(monthStart) {
+				skew = monthStart.Sub(trade.Time)
+			} else if !trade.Time.Before(monthEnd) {
+				skew = trade.Time.Sub(monthEnd)
+			}
+			if skew > monthSkewTolerance {
+				skewed++
+				if skew > maxSkew {
+					maxSkew = skew
+				}
+			}
+		}
 
-	// Parse CSV and aggregate
-	totalTrades, err := parser.ParseCSV(job.CSVPath, func(trades []parser.Trade) error {
+		aggStart := time.Now()
+		trades = p.applyFilter(trades)
 		p.aggregator.ProcessTrades(trades)
+		p.times.add(&p.times.aggregate, aggStart)
 		return nil
 	})
+	p.times.parse.Add(int64(time.Since(parseStart)) - (p.times.aggregate.Load() - aggBefore))
 
 	if err != nil {
 		result.Error = err
@@ -417,11 +1331,21 @@ func (p *Pipeline) processMonth(job Job) Result {
 
 	result.Trades = totalTrades
 
+	// Bars are keyed by trade timestamp, so out-of-month trades already
+	// land in their actual hours; the labeled month just didn't contain
+	// them. Report so corrupt or mislabeled archives are noticed.
+	if skewed > 0 {
+		p.ui.LogWarning("%s: %d trades fall outside the file's month (max skew %s); aggregated into their actual hours",
+			job.Month, skewed, maxSkew.Round(time.Second))
+	}
+
 	// Clean up CSV file
 	os.Remove(job.CSVPath)
 
 	// Save checkpoint (bars + detector + state)
+	writeStart := time.Now()
 	hours, err := p.saveCheckpoint(job.Month)
+	p.times.add(&p.times.write, writeStart)
 	if err != nil {
 		p.ui.LogError("Failed to save checkpoint: %v", err)
 	}
@@ -430,23 +1354,173 @@ func (p *Pipeline) processMonth(job Job) Result {
 	return result
 }
 
+// skipIDs reports whether the per-trade first/last fill IDs can go
+// unparsed: they only feed avg_fills_per_aggtrade, so a run whose column
+// selection excludes it doesn't need them tokenized
+func (p *Pipeline) skipIDs() bool {
+	if p.cfg.Columns == "" {
+		return false
+	}
+	for _, name := range strings.Split(p.cfg.Columns, ",") {
+		if strings.TrimSpace(name) == "avg_fills_per_aggtrade" {
+			return false
+		}
+	}
+	return true
+}
+
+// applyFilter drops trades outside the configured day-precision range and
+// trades failing the filter expression, tracking kept/dropped counts
+func (p *Pipeline) applyFilter(trades []parser.Trade) []parser.Trade {
+	hasRange := !p.cfg.RangeStart.IsZero() || !p.cfg.RangeEnd.IsZero()
+	if p.filter == nil && !hasRange && len(p.cfg.ExcludeWindows) == 0 {
+		return trades
+	}
+
+	var dropped int64
+	kept := trades[:0]
+	for i := range trades {
+		if !p.cfg.RangeStart.IsZero() && trades[i].Time.Before(p.cfg.RangeStart) {
+			continue
+		}
+		if !p.cfg.RangeEnd.IsZero() && !trades[i].Time.Before(p.cfg.RangeEnd) {
+			continue
+		}
+		if p.inExcludedWindow(trades[i].Time) {
+			continue
+		}
+		if p.filter != nil && !p.filter.Match(&trades[i]) {
+			dropped++
+			continue
+		}
+		kept = append(kept, trades[i])
+	}
+
+	if p.filter != nil {
+		p.keptCount.Add(int64(len(kept)))
+		p.filteredCount.Add(dropped)
+	}
+	return kept
+}
+
+// inExcludedWindow returns true if the timestamp falls in a configured
+// exclusion window
+func (p *Pipeline) inExcludedWindow(t time.Time) bool {
+	for _, w := range p.cfg.ExcludeWindows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
 // saveCheckpoint saves the current state after completing a month.
 // Order matters: bars → detector → state (state.json marks completion)
 // Returns the number of hours saved.
 func (p *Pipeline) saveCheckpoint(month string) (int, error) {
+	// 0. Re-derive bars revised by late trades under the revise policy.
+	// Revisions to bars not yet published are folded into this
+	// checkpoint; revisions to already-published months come back as
+	// corrections handled below.
+	corrections := p.aggregator.DrainRevised()
+
 	// 1. Get ALL bars for this month (pending + finalized) and save
 	bars := p.aggregator.GetBarsForMonth(month)
 	if err := state.SaveMonthBars(p.stateMgr.BarsDir(), month, bars); err != nil {
 		return 0, fmt.Errorf("save bars: %w", err)
 	}
 
-	// 2. Save detector state
-	if err := state.SaveDetector(p.stateMgr.DetectorPath(), p.aggregator.Detector()); err != nil {
-		return len(bars), fmt.Errorf("save detector: %w", err)
+	// 1a. Evaluate alert rules and publish events for the freshly
+	// finalized bars
+	if p.alerts != nil {
+		for _, hr := range bars {
+			p.alerts.Evaluate(hr.Time, hr.Bar)
+		}
+	}
+	if p.events != nil {
+		for _, hr := range bars {
+			p.events.Publish(hr.Time, hr.Bar)
+		}
+	}
+
+	// 1a'. Rewrite the bar files of months revised by late trades so the
+	// final output sees the revisions, and re-publish the revised bars as
+	// correction events
+	if len(corrections) > 0 {
+		revisedMonths := make(map[string]bool)
+		for _, hr := range corrections {
+			revisedMonths[hr.Time.Format("2006-01")] = true
+		}
+		for m := range revisedMonths {
+			if err := state.SaveMonthBars(p.stateMgr.BarsDir(), m, p.aggregator.ClosedBarsForMonth(m)); err != nil {
+				return len(bars), fmt.Errorf("rewrite revised month %s: %w", m, err)
+			}
+		}
+		if p.events != nil {
+			for _, hr := range corrections {
+				p.events.Publish(hr.Time, hr.Bar)
+			}
+		}
+	}
+
+	// 1b. Save top trades for the month if tracking is enabled
+	if p.cfg.TopKTrades > 0 {
+		topTrades := p.aggregator.GetTopTradesForMonth(month)
+		if err := state.SaveMonthTopTrades(p.stateMgr.TopTradesDir(), month, topTrades); err != nil {
+			return 0, fmt.Errorf("save top trades: %w", err)
+		}
+	}
+
+	// 2. Save detector state. Skipped for a month assembled from daily
+	// files: the next run reprocesses it in full, and persisting its
+	// samples now would double-count them in the reservoirs then.
+	partial := p.isPartialMonth(month)
+	if !partial {
+		if err := state.SaveDetector(p.stateMgr.DetectorPath(), p.aggregator.Detector()); err != nil {
+			return len(bars), fmt.Errorf("save detector: %w", err)
+		}
+
+		// 2a. The comparison detector keeps its own reservoirs; persist
+		// them too so the _w<days> columns don't re-enter warmup on resume
+		if cd := p.aggregator.CompareDetector(); cd != nil {
+			if err := state.SaveDetector(p.stateMgr.ComparePath(), cd); err != nil {
+				return len(bars), fmt.Errorf("save compare detector: %w", err)
+			}
+		}
+
+		// 2b. Save seasonal hour-of-day volumes
+		if err := state.SaveSeasonal(p.stateMgr.SeasonalPath(), p.aggregator.Seasonal().Export()); err != nil {
+			return len(bars), fmt.Errorf("save seasonal: %w", err)
+		}
+
+		// 2c. Save the lot-size regime tracker
+		if err := state.SaveLot(p.stateMgr.LotPath(), p.aggregator.Lot().Export()); err != nil {
+			return len(bars), fmt.Errorf("save lot tracker: %w", err)
+		}
 	}
 
-	// 3. Update and save global state (marks month as completed)
-	p.state.CompletedMonths = append(p.state.CompletedMonths, month)
+	// 3. Update and save global state. An assembled month is inherently
+	// partial — more days, and eventually the monthly archive, are
+	// published later — so it is recorded as partial rather than
+	// completed and the next run re-fetches and replaces it.
+	if partial {
+		p.state.MarkPartial(month)
+	} else {
+		p.state.ClearPartial(month)
+		p.state.CompletedMonths = append(p.state.CompletedMonths, month)
+	}
+	p.sourceMu.Lock()
+	if info, ok := p.sourceHashes[month]; ok {
+		if p.state.Sources == nil {
+			p.state.Sources = make(map[string]state.SourceInfo)
+		}
+		p.state.Sources[month] = info
+	}
+	p.sourceMu.Unlock()
+	p.state.TradesPerByte = p.estimator.TradesPerByte()
+	if ratio := p.decompressRatio(); ratio != config.DecompressionRatio {
+		p.state.DecompressRatio = ratio
+	}
 	if err := p.stateMgr.Save(p.state); err != nil {
 		return len(bars), fmt.Errorf("save state: %w", err)
 	}
@@ -488,6 +1562,16 @@ func (p *Pipeline) resultCollector() {
 	}
 }
 
+// LoadTopTrades loads the per-hour top trades for all months from the
+// checkpoint. Only meaningful when top-trade tracking is enabled.
+func (p *Pipeline) LoadTopTrades() ([]aggregator.HourTopTrades, error) {
+	months, err := p.cfg.GenerateMonths()
+	if err != nil {
+		return nil, err
+	}
+	return state.LoadAllTopTrades(p.stateMgr.TopTradesDir(), months)
+}
+
 // loadExistingResults loads bars from all completed months
 func (p *Pipeline) loadExistingResults(months []string) ([]aggregator.HourlyResult, error) {
 	return state.LoadAllBars(p.stateMgr.BarsDir(), months)
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// phaseTimes accumulates wall time spent in each pipeline phase across all
+// workers, in nanoseconds
+type phaseTimes struct {
+	download  atomic.Int64
+	extract   atomic.Int64
+	parse     atomic.Int64
+	aggregate atomic.Int64
+	write     atomic.Int64
+}
+
+func (t *phaseTimes) add(counter *atomic.Int64, start time.Time) {
+	counter.Add(int64(time.Since(start)))
+}
+
+// TimingReport is the per-phase time breakdown of a run
+type TimingReport struct {
+	Download  time.Duration
+	Extract   time.Duration
+	Parse     time.Duration
+	Aggregate time.Duration
+	Write     time.Duration
+}
+
+// TimingReport returns the accumulated per-phase breakdown
+func (p *Pipeline) TimingReport() TimingReport {
+	return TimingReport{
+		Download:  time.Duration(p.times.download.Load()),
+		Extract:   time.Duration(p.times.extract.Load()),
+		Parse:     time.Duration(p.times.parse.Load()),
+		Aggregate: time.Duration(p.times.aggregate.Load()),
+		Write:     time.Duration(p.times.write.Load()),
+	}
+}
+
+// Total returns the summed phase time (worker time, not wall time)
+func (r TimingReport) Total() time.Duration {
+	return r.Download + r.Extract + r.Parse + r.Aggregate + r.Write
+}
+
+// String formats the breakdown as one line per phase with percentages
+func (r TimingReport) String() string {
+	total := r.Total()
+	if total == 0 {
+		return "no timing data"
+	}
+	pct := func(d time.Duration) float64 {
+		return float64(d) / float64(total) * 100
+	}
+	return fmt.Sprintf(
+		"download %s (%.0f%%), extract %s (%.0f%%), parse %s (%.0f%%), aggregate %s (%.0f%%), write %s (%.0f%%)",
+		r.Download.Round(time.Second), pct(r.Download),
+		r.Extract.Round(time.Second), pct(r.Extract),
+		r.Parse.Round(time.Second), pct(r.Parse),
+		r.Aggregate.Round(time.Second), pct(r.Aggregate),
+		r.Write.Round(time.Second), pct(r.Write),
+	)
+}
+
+// Suggestion proposes a tuning change based on where the time went
+func (r TimingReport) Suggestion() string {
+	total := r.Total()
+	if total == 0 {
+		return ""
+	}
+
+	network := r.Download
+	cpu := r.Parse + r.Aggregate
+	disk := r.Extract + r.Write
+
+	switch {
+	case network > cpu+disk:
+		return "downloads dominate; try more --download-workers or a faster connection"
+	case cpu > network+disk:
+		return "parsing/aggregation dominates; the run is CPU-bound, extra download workers won't help"
+	case disk > network+cpu:
+		return "extraction/checkpointing dominates; a faster temp disk would help most"
+	default:
+		return "phases are balanced; current settings look reasonable"
+	}
+}
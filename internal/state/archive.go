@@ -0,0 +1,140 @@
+package state
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Export bundles the checkpoint directory (state.json, detector.json, bars/)
+// into a single zstd-compressed tar archive so a long run can be migrated
+// to another machine.
+func (m *Manager) Export(archivePath string) error {
+	if _, err := os.Stat(filepath.Join(m.dir, stateFileName)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no checkpoint found in %s", m.dir)
+		}
+		return err
+	}
+
+	out, err := os.Create(archivePath)
+	if err != nil {
+		return fmt.Errorf("create archive: %w", err)
+	}
+	defer out.Close()
+
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return fmt.Errorf("create zstd writer: %w", err)
+	}
+	tw := tar.NewWriter(zw)
+
+	err = filepath.Walk(m.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		// Skip temp files from interrupted writes
+		if strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(m.dir, path)
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("archive checkpoint: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Import restores a checkpoint archive into the manager's directory.
+// Refuses to overwrite an existing checkpoint unless overwrite is set.
+func (m *Manager) Import(archivePath string, overwrite bool) error {
+	if _, err := os.Stat(filepath.Join(m.dir, stateFileName)); err == nil && !overwrite {
+		return fmt.Errorf("checkpoint already exists in %s (use --overwrite to replace)", m.dir)
+	}
+
+	in, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer in.Close()
+
+	zr, err := zstd.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("create zstd reader: %w", err)
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Reject path traversal attempts
+		name := filepath.FromSlash(header.Name)
+		if strings.Contains(name, "..") || filepath.IsAbs(name) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		destPath := filepath.Join(m.dir, name)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+
+		file, err := os.Create(destPath)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, tr); err != nil {
+			file.Close()
+			return fmt.Errorf("extract %s: %w", header.Name, err)
+		}
+		file.Close()
+	}
+
+	return nil
+}
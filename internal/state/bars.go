@@ -10,37 +10,9 @@ import (
 	"github.com/clement/aggtrades/internal/aggregator"
 )
 
-// SerializedBar is a JSON-friendly representation of HourlyResult.
-type SerializedBar struct {
-	Time int64 `json:"t"`
-
-	BuyVol  float64 `json:"bv"`
-	SellVol float64 `json:"sv"`
-
-	NTrades   int64 `json:"nt"`
-	BuyCount  int64 `json:"bc"`
-	SellCount int64 `json:"sc"`
-
-	WhaleBuyVolP99    float64 `json:"wbv99"`
-	WhaleSellVolP99   float64 `json:"wsv99"`
-	WhaleBuyCountP99  int64   `json:"wbc99"`
-	WhaleSellCountP99 int64   `json:"wsc99"`
-
-	WhaleBuyVolP999    float64 `json:"wbv999"`
-	WhaleSellVolP999   float64 `json:"wsv999"`
-	WhaleBuyCountP999  int64   `json:"wbc999"`
-	WhaleSellCountP999 int64   `json:"wsc999"`
-
-	VolFirst30Min float64 `json:"vf30"`
-	VolLast30Min  float64 `json:"vl30"`
-
-	BuyVolUSD  float64 `json:"bvu"`
-	SellVolUSD float64 `json:"svu"`
-
-	MaxTradeSize float64 `json:"mts"`
-	VWAP         float64 `json:"vwap"`
-	PriceStd     float64 `json:"pstd"`
-}
+// serializedBar is the JSON checkpoint representation of one hourly bar,
+// keyed by the short keys from the column registry plus "t" for the hour.
+type serializedBar map[string]any
 
 // SaveMonthBars saves hourly bars for a month to disk.
 func SaveMonthBars(dir, month string, bars []aggregator.HourlyResult) error {
@@ -48,7 +20,7 @@ func SaveMonthBars(dir, month string, bars []aggregator.HourlyResult) error {
 		return err
 	}
 
-	serialized := make([]SerializedBar, len(bars))
+	serialized := make([]serializedBar, len(bars))
 	for i, b := range bars {
 		serialized[i] = serializeBar(b)
 	}
@@ -74,7 +46,7 @@ func LoadMonthBars(dir, month string) ([]aggregator.HourlyResult, error) {
 		return nil, err
 	}
 
-	var serialized []SerializedBar
+	var serialized []serializedBar
 	if err := json.Unmarshal(data, &serialized); err != nil {
 		return nil, nil // Corrupted
 	}
@@ -87,7 +59,11 @@ func LoadMonthBars(dir, month string) ([]aggregator.HourlyResult, error) {
 	return bars, nil
 }
 
-// LoadAllBars loads bars for all specified months.
+// LoadAllBars loads bars for all specified months. Hours appearing in more
+// than one month file (a boundary hour checkpointed while still pending,
+// then again after finalizing in the next month's save) are deduplicated,
+// keeping the later save, so a crash between checkpoints never yields
+// duplicated rows in the output on resume.
 func LoadAllBars(dir string, months []string) ([]aggregator.HourlyResult, error) {
 	var allBars []aggregator.HourlyResult
 
@@ -101,63 +77,45 @@ func LoadAllBars(dir string, months []string) ([]aggregator.HourlyResult, error)
 		}
 	}
 
-	sort.Slice(allBars, func(i, j int) bool {
+	sort.SliceStable(allBars, func(i, j int) bool {
 		return allBars[i].Time.Before(allBars[j].Time)
 	})
 
-	return allBars, nil
+	deduped := allBars[:0]
+	for i, bar := range allBars {
+		if i+1 < len(allBars) && allBars[i+1].Time.Equal(bar.Time) {
+			continue // a later save supersedes this hour
+		}
+		deduped = append(deduped, bar)
+	}
+
+	return deduped, nil
 }
 
-func serializeBar(b aggregator.HourlyResult) SerializedBar {
-	return SerializedBar{
-		Time:               b.Time.Unix(),
-		BuyVol:             b.Bar.BuyVol,
-		SellVol:            b.Bar.SellVol,
-		NTrades:            b.Bar.NTrades,
-		BuyCount:           b.Bar.BuyCount,
-		SellCount:          b.Bar.SellCount,
-		WhaleBuyVolP99:     b.Bar.WhaleBuyVolP99,
-		WhaleSellVolP99:    b.Bar.WhaleSellVolP99,
-		WhaleBuyCountP99:   b.Bar.WhaleBuyCountP99,
-		WhaleSellCountP99:  b.Bar.WhaleSellCountP99,
-		WhaleBuyVolP999:    b.Bar.WhaleBuyVolP999,
-		WhaleSellVolP999:   b.Bar.WhaleSellVolP999,
-		WhaleBuyCountP999:  b.Bar.WhaleBuyCountP999,
-		WhaleSellCountP999: b.Bar.WhaleSellCountP999,
-		VolFirst30Min:      b.Bar.VolFirst30Min,
-		VolLast30Min:       b.Bar.VolLast30Min,
-		BuyVolUSD:          b.Bar.BuyVolUSD,
-		SellVolUSD:         b.Bar.SellVolUSD,
-		MaxTradeSize:       b.Bar.MaxTradeSize,
-		VWAP:               b.Bar.VWAP,
-		PriceStd:           b.Bar.PriceStd,
+func serializeBar(b aggregator.HourlyResult) serializedBar {
+	s := make(serializedBar, len(aggregator.Columns)+1)
+	s["t"] = b.Time.Unix()
+	for _, col := range aggregator.Columns {
+		s[col.ShortKey] = col.Value(b.Bar)
 	}
+	return s
 }
 
-func deserializeBar(s SerializedBar) aggregator.HourlyResult {
+func deserializeBar(s serializedBar) aggregator.HourlyResult {
+	bar := aggregator.NewHourlyBar()
+	for _, col := range aggregator.Columns {
+		if v, ok := s[col.ShortKey]; ok {
+			col.Set(bar, v)
+		}
+	}
+
+	var unix int64
+	if t, ok := s["t"].(float64); ok {
+		unix = int64(t)
+	}
+
 	return aggregator.HourlyResult{
-		Time: time.Unix(s.Time, 0).UTC(),
-		Bar: &aggregator.HourlyBar{
-			BuyVol:             s.BuyVol,
-			SellVol:            s.SellVol,
-			NTrades:            s.NTrades,
-			BuyCount:           s.BuyCount,
-			SellCount:          s.SellCount,
-			WhaleBuyVolP99:     s.WhaleBuyVolP99,
-			WhaleSellVolP99:    s.WhaleSellVolP99,
-			WhaleBuyCountP99:   s.WhaleBuyCountP99,
-			WhaleSellCountP99:  s.WhaleSellCountP99,
-			WhaleBuyVolP999:    s.WhaleBuyVolP999,
-			WhaleSellVolP999:   s.WhaleSellVolP999,
-			WhaleBuyCountP999:  s.WhaleBuyCountP999,
-			WhaleSellCountP999: s.WhaleSellCountP999,
-			VolFirst30Min:      s.VolFirst30Min,
-			VolLast30Min:       s.VolLast30Min,
-			BuyVolUSD:          s.BuyVolUSD,
-			SellVolUSD:         s.SellVolUSD,
-			MaxTradeSize:       s.MaxTradeSize,
-			VWAP:               s.VWAP,
-			PriceStd:           s.PriceStd,
-		},
+		Time: time.Unix(unix, 0).UTC(),
+		Bar:  bar,
 	}
 }
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Info summarizes a checkpoint without modifying it.
+type Info struct {
+	CompletedMonths []string  `json:"completed_months"`
+	LastUpdate      time.Time `json:"last_update"`
+	DetectorDates   []string  `json:"detector_dates"`
+	Bootstrapped    bool      `json:"bootstrapped"`
+	BarFileCount    int       `json:"bar_file_count"`
+	TotalSizeBytes  int64     `json:"total_size_bytes"`
+}
+
+// Inspect reads checkpoint metadata from disk. Returns nil if no state exists.
+func Inspect(m *Manager) (*Info, error) {
+	st, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return nil, nil
+	}
+
+	info := &Info{
+		CompletedMonths: st.CompletedMonths,
+		LastUpdate:      st.LastUpdate,
+	}
+
+	detector, err := LoadDetector(m.DetectorPath())
+	if err != nil {
+		return nil, err
+	}
+	if detector != nil {
+		info.DetectorDates = detector.DateOrder
+		info.Bootstrapped = detector.Bootstrap.Bootstrapped
+	}
+
+	// Sum file sizes and count bar files
+	err = filepath.Walk(m.dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+		info.TotalSizeBytes += fi.Size()
+		if filepath.Dir(path) == m.BarsDir() && strings.HasSuffix(path, ".json") {
+			info.BarFileCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
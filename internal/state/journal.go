@@ -0,0 +1,65 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JournalEntry records one completed network download for dataset
+// provenance audits
+type JournalEntry struct {
+	Time     time.Time `json:"time"`
+	Month    string    `json:"month"`
+	URL      string    `json:"url"`
+	Bytes    int64     `json:"bytes"`
+	SHA256   string    `json:"sha256,omitempty"`
+	Duration float64   `json:"duration_secs"`
+	Attempts int       `json:"attempts"`
+}
+
+// Journal is an append-only log of downloads, kept alongside the rest of
+// the checkpoint state. Entries are JSON lines; the file is only ever
+// appended to, so past runs remain auditable.
+type Journal struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewJournal returns a journal writing to downloads.jsonl in dir
+func NewJournal(dir string) *Journal {
+	return &Journal{path: filepath.Join(dir, "downloads.jsonl")}
+}
+
+// Path returns the journal file location
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Append appends one entry to the journal
+func (j *Journal) Append(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Lockfile pins the archive checksums a dataset was built from, so a later
+// reprocessing run fails loudly if an upstream monthly file was silently
+// replaced instead of producing subtly different bars.
+type Lockfile struct {
+	Symbol    string                `json:"symbol"`
+	CreatedAt time.Time             `json:"created_at"`
+	Sources   map[string]SourceInfo `json:"sources"`
+}
+
+// LoadLockfile reads a lockfile. A missing file is not an error: it returns
+// nil, meaning "nothing pinned yet".
+func LoadLockfile(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var lf Lockfile
+	if err := json.Unmarshal(data, &lf); err != nil {
+		return nil, fmt.Errorf("parse lockfile: %w", err)
+	}
+	return &lf, nil
+}
+
+// WriteLockfile writes a lockfile atomically.
+func WriteLockfile(path string, lf *Lockfile) error {
+	data, err := json.MarshalIndent(lf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicWrite(path, data)
+}
@@ -0,0 +1,47 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+const lotFileName = "lot.json"
+
+// LotPath returns the lot tracker state file path.
+func (m *Manager) LotPath() string {
+	return filepath.Join(m.dir, lotFileName)
+}
+
+// SaveLot persists the lot-size regime tracker state.
+func SaveLot(path string, s aggregator.LotState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return atomicWrite(path, data)
+}
+
+// LoadLot loads the lot tracker state. Returns nil if none exists.
+func LoadLot(path string) (*aggregator.LotState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var s aggregator.LotState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, nil // Corrupted, start fresh
+	}
+	return &s, nil
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+const seasonalFileName = "seasonal.json"
+
+// SeasonalPath returns the seasonal tracker state file path.
+func (m *Manager) SeasonalPath() string {
+	return filepath.Join(m.dir, seasonalFileName)
+}
+
+// SaveSeasonal persists the per-date hour-of-day volume totals.
+func SaveSeasonal(path string, volumes map[string][]float64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(volumes)
+	if err != nil {
+		return err
+	}
+
+	return atomicWrite(path, data)
+}
+
+// LoadSeasonal loads the seasonal tracker state. Returns nil if none exists.
+func LoadSeasonal(path string) (map[string][]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var volumes map[string][]float64
+	if err := json.Unmarshal(data, &volumes); err != nil {
+		return nil, nil // Corrupted, start fresh
+	}
+	return volumes, nil
+}
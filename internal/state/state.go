@@ -11,6 +11,7 @@ const (
 	currentVersion = 1
 	stateFileName  = "state.json"
 	detectorFileName = "detector.json"
+	compareFileName  = "compare.json"
 	barsDirName    = "bars"
 )
 
@@ -19,6 +20,35 @@ type State struct {
 	Version         int       `json:"version"`
 	CompletedMonths []string  `json:"completed_months"`
 	LastUpdate      time.Time `json:"last_update"`
+
+	// PartialMonths lists months assembled from daily files, still
+	// awaiting more days or the monthly archive. They are excluded from
+	// completion, so the next run re-fetches and replaces them.
+	PartialMonths []string `json:"partial_months,omitempty"`
+
+	// Fingerprint captures the config settings the bars were computed under,
+	// so resumes with incompatible settings can be detected.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// TradesPerByte is the calibrated trades-per-CSV-byte ratio used to
+	// seed memory estimation on resume.
+	TradesPerByte float64 `json:"trades_per_byte,omitempty"`
+
+	// DecompressRatio is the observed CSV-to-ZIP size ratio for this
+	// symbol, used for disk preflight checks on resume. Alt pairs compress
+	// very differently from BTCUSDT.
+	DecompressRatio float64 `json:"decompress_ratio,omitempty"`
+
+	// Sources records the checksum and size of each month's source ZIP,
+	// for the reproducibility manifest. Months processed from an already
+	// extracted CSV have no entry.
+	Sources map[string]SourceInfo `json:"sources,omitempty"`
+}
+
+// SourceInfo identifies one month's source ZIP as downloaded.
+type SourceInfo struct {
+	SHA256 string `json:"sha256"`
+	Bytes  int64  `json:"bytes"`
 }
 
 // Manager handles state persistence.
@@ -46,6 +76,11 @@ func (m *Manager) DetectorPath() string {
 	return filepath.Join(m.dir, detectorFileName)
 }
 
+// ComparePath returns the comparison detector state file path.
+func (m *Manager) ComparePath() string {
+	return filepath.Join(m.dir, compareFileName)
+}
+
 // Load loads the state from disk. Returns nil if no state exists.
 func (m *Manager) Load() (*State, error) {
 	path := filepath.Join(m.dir, stateFileName)
@@ -97,6 +132,34 @@ func (st *State) IsCompleted(month string) bool {
 	return false
 }
 
+// IsPartial checks if a month was assembled from daily files and is
+// still awaiting more data.
+func (st *State) IsPartial(month string) bool {
+	for _, m := range st.PartialMonths {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkPartial records a month as partial. Idempotent.
+func (st *State) MarkPartial(month string) {
+	if !st.IsPartial(month) {
+		st.PartialMonths = append(st.PartialMonths, month)
+	}
+}
+
+// ClearPartial removes a month's partial mark once it completes.
+func (st *State) ClearPartial(month string) {
+	for i, m := range st.PartialMonths {
+		if m == month {
+			st.PartialMonths = append(st.PartialMonths[:i], st.PartialMonths[i+1:]...)
+			return
+		}
+	}
+}
+
 // GetPendingMonths returns months that need processing.
 func (st *State) GetPendingMonths(allMonths []string) []string {
 	if st == nil {
@@ -117,6 +180,36 @@ func (m *Manager) Clear() error {
 	return os.RemoveAll(m.dir)
 }
 
+// PruneBars removes the per-month bar JSONs while keeping state.json and the
+// detector state, so a future run can extend the range without recomputing
+// percentile history. Returns the number of files removed and their size.
+func (m *Manager) PruneBars() (int, int64, error) {
+	entries, err := os.ReadDir(m.BarsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, err
+	}
+
+	var removed int
+	var bytes int64
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(m.BarsDir(), entry.Name())
+		if info, err := entry.Info(); err == nil {
+			bytes += info.Size()
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, bytes, err
+		}
+		removed++
+	}
+	return removed, bytes, nil
+}
+
 // atomicWrite writes data to path atomically using a temp file.
 func atomicWrite(path string, data []byte) error {
 	tmpPath := path + ".tmp"
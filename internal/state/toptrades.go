@@ -0,0 +1,60 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/clement/aggtrades/internal/aggregator"
+)
+
+const topTradesDirName = "toptrades"
+
+// TopTradesDir returns the top-trades subdirectory path.
+func (m *Manager) TopTradesDir() string {
+	return filepath.Join(m.dir, topTradesDirName)
+}
+
+// SaveMonthTopTrades saves per-hour top trades for a month to disk.
+func SaveMonthTopTrades(dir, month string, hours []aggregator.HourTopTrades) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(hours)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, month+".json")
+	return atomicWrite(path, data)
+}
+
+// LoadAllTopTrades loads top trades for all specified months.
+func LoadAllTopTrades(dir string, months []string) ([]aggregator.HourTopTrades, error) {
+	var all []aggregator.HourTopTrades
+
+	for _, month := range months {
+		path := filepath.Join(dir, month+".json")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		var hours []aggregator.HourTopTrades
+		if err := json.Unmarshal(data, &hours); err != nil {
+			continue // Corrupted
+		}
+		all = append(all, hours...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Hour.Before(all[j].Hour)
+	})
+
+	return all, nil
+}
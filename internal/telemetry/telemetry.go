@@ -0,0 +1,65 @@
+// Package telemetry posts opt-in, anonymous run summaries to a
+// user-controlled endpoint. Only durations, throughput, and error classes
+// are sent — never data content or file paths.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// postTimeout bounds how long a telemetry post may delay shutdown
+const postTimeout = 5 * time.Second
+
+// Summary is one run's performance report
+type Summary struct {
+	Symbol       string  `json:"symbol"`
+	Months       int     `json:"months"`
+	Hours        int     `json:"hours,omitempty"`
+	Trades       int64   `json:"trades,omitempty"`
+	DurationSec  float64 `json:"duration_sec"`
+	TradesPerSec float64 `json:"trades_per_sec,omitempty"`
+
+	// Per-phase worker time in seconds
+	DownloadSec  float64 `json:"download_sec,omitempty"`
+	ExtractSec   float64 `json:"extract_sec,omitempty"`
+	ParseSec     float64 `json:"parse_sec,omitempty"`
+	AggregateSec float64 `json:"aggregate_sec,omitempty"`
+	WriteSec     float64 `json:"write_sec,omitempty"`
+
+	// ErrorClass is a coarse failure category ("" on success)
+	ErrorClass string `json:"error_class,omitempty"`
+}
+
+// Post sends a run summary to the endpoint. Failures are returned, not
+// retried — telemetry must never affect the run outcome.
+func Post(endpoint string, s Summary) error {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), postTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
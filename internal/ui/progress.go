@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// Snapshot is a point-in-time progress summary, written as progress.json so
+// external scripts and dashboards can poll a run without scraping the TUI
+// or the control API
+type Snapshot struct {
+	Symbol      string  `json:"symbol"`
+	StartDate   string  `json:"start_date"`
+	EndDate     string  `json:"end_date"`
+	MonthsDone  int     `json:"months_done"`
+	MonthsTotal int     `json:"months_total"`
+	PctComplete float64 `json:"pct_complete"`
+
+	// Months currently being downloaded/extracted/processed
+	Downloading []string `json:"downloading,omitempty"`
+	Processing  []string `json:"processing,omitempty"`
+
+	TradesPerSec float64 `json:"trades_per_sec"`
+	MBPerSec     float64 `json:"mb_per_sec"`
+
+	// ETASeconds is -1 until enough months have completed to estimate
+	ETASeconds float64 `json:"eta_seconds"`
+
+	MemoryGB    float64   `json:"memory_gb"`
+	MemoryMaxGB float64   `json:"memory_max_gb"`
+	LastError   string    `json:"last_error,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Snapshot returns the current progress summary
+func (u *UI) Snapshot() Snapshot {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	snap := Snapshot{
+		Symbol:      u.symbol,
+		StartDate:   u.startDate,
+		EndDate:     u.endDate,
+		MonthsDone:  u.completedCount,
+		MonthsTotal: u.totalMonths,
+		Downloading: sortedKeys(u.downloadWorkers),
+		Processing:  sortedKeys(u.processWorkers),
+		MemoryGB:    u.memActualGB,
+		MemoryMaxGB: u.memMaxGB,
+		LastError:   u.lastError,
+		ETASeconds:  -1,
+		UpdatedAt:   u.clock.Now().UTC(),
+	}
+	if u.totalMonths > 0 {
+		snap.PctComplete = float64(u.completedCount) / float64(u.totalMonths) * 100
+	}
+	snap.TradesPerSec, snap.MBPerSec = u.throughput()
+	if eta, ok := u.etaDuration(); ok {
+		snap.ETASeconds = eta.Seconds()
+	}
+	return snap
+}
+
+// StartProgressWriter periodically rewrites path with the current snapshot
+// until Stop. Writes are atomic (tmp + rename) so pollers never read a
+// partial file; write failures are silently dropped, progress reporting
+// must never break a run.
+func (u *UI) StartProgressWriter(path string, interval time.Duration) {
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+
+		ticker := u.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-u.stopChan:
+				// Final state for pollers that outlive the run
+				u.writeSnapshot(path)
+				return
+			case <-ticker.C():
+				u.writeSnapshot(path)
+			}
+		}
+	}()
+}
+
+func (u *UI) writeSnapshot(path string) {
+	data, err := json.MarshalIndent(u.Snapshot(), "", "  ")
+	if err != nil {
+		return
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tmpPath, path)
+}
+
+func sortedKeys(workers map[string]struct{}) []string {
+	if len(workers) == 0 {
+		return nil
+	}
+	months := make([]string, 0, len(workers))
+	for month := range workers {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	return months
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/clement/aggtrades/clock"
 	"github.com/pterm/pterm"
 )
 
@@ -14,15 +15,17 @@ type WorkerType int
 
 const (
 	WorkerDownload WorkerType = iota
+	WorkerExtract
 	WorkerProcess
 )
 
 // WorkerUpdate represents a worker status update
 type WorkerUpdate struct {
-	Type      WorkerType
-	Month     string
-	Active    bool
-	BytesDown int64 // For download progress
+	Type       WorkerType
+	Month      string
+	Active     bool
+	BytesDown  int64 // For download/extract progress
+	BytesTotal int64 // Expected total bytes (0 if unknown)
 }
 
 // MonthComplete represents a completed month
@@ -49,8 +52,20 @@ type UI struct {
 
 	// Worker tracking
 	downloadWorkers map[string]struct{}
+	extractWorkers  map[string]struct{}
 	processWorkers  map[string]struct{}
 
+	// Extraction progress per month (0-100, -1 if unknown)
+	extractPct map[string]float64
+
+	// Known month sizes for byte-weighted ETA
+	monthSizes     map[string]int64
+	totalBytes     int64
+	completedBytes int64
+
+	// Cumulative trades processed, for throughput display
+	totalTradesDone int64
+
 	// Memory stats
 	memReservedGB float64
 	memActualGB   float64
@@ -59,6 +74,12 @@ type UI struct {
 	// Recent completions
 	recentCompletions []MonthComplete
 
+	// Most recent error message, surfaced in progress snapshots
+	lastError string
+
+	// Injectable clock (defaults to the real clock)
+	clock clock.Clock
+
 	// Channels for updates
 	WorkerChan   chan WorkerUpdate
 	CompleteChan chan MonthComplete
@@ -78,20 +99,34 @@ func New(enabled bool, symbol, startDate, endDate string, totalMonths int, maxMe
 		startDate:        startDate,
 		endDate:          endDate,
 		maxMemoryGB:      maxMemoryGB,
-		startTime:        time.Now(),
+		clock:            clock.New(),
 		downloadWorkers:  make(map[string]struct{}),
+		extractWorkers:   make(map[string]struct{}),
 		processWorkers:   make(map[string]struct{}),
+		extractPct:       make(map[string]float64),
 		recentCompletions: make([]MonthComplete, 0, 5),
 		WorkerChan:       make(chan WorkerUpdate, 100),
 		CompleteChan:     make(chan MonthComplete, 100),
 		stopChan:         make(chan struct{}),
 	}
+	u.startTime = u.clock.Now()
 	return u
 }
 
+// SetClock replaces the clock, for deterministic tests. Must be called
+// before Start.
+func (u *UI) SetClock(c clock.Clock) {
+	u.clock = c
+	u.startTime = c.Now()
+}
+
 // Start begins the UI update loop
 func (u *UI) Start() {
 	if !u.enabled {
+		// Plain-log mode still needs the update channels drained, and
+		// month completions logged with throughput
+		u.wg.Add(1)
+		go u.plainLoop()
 		return
 	}
 
@@ -127,6 +162,19 @@ func (u *UI) Stop() {
 	}
 }
 
+// SetMonthSizes provides expected download sizes for the months still to
+// process, enabling byte-weighted ETA instead of counting months equally
+func (u *UI) SetMonthSizes(sizes map[string]int64) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.monthSizes = sizes
+	u.totalBytes = 0
+	for _, size := range sizes {
+		u.totalBytes += size
+	}
+}
+
 // UpdateMemory updates memory statistics
 func (u *UI) UpdateMemory(reservedGB, actualGB, maxGB float64) {
 	u.mu.Lock()
@@ -139,7 +187,7 @@ func (u *UI) UpdateMemory(reservedGB, actualGB, maxGB float64) {
 func (u *UI) updateLoop() {
 	defer u.wg.Done()
 
-	ticker := time.NewTicker(100 * time.Millisecond)
+	ticker := u.clock.NewTicker(100 * time.Millisecond)
 	defer ticker.Stop()
 
 	for {
@@ -153,12 +201,50 @@ func (u *UI) updateLoop() {
 		case complete := <-u.CompleteChan:
 			u.handleComplete(complete)
 
-		case <-ticker.C:
+		case <-ticker.C():
 			u.render()
 		}
 	}
 }
 
+// plainLoop consumes updates when the TUI is disabled, emitting one log
+// line per completed month with cumulative throughput
+func (u *UI) plainLoop() {
+	defer u.wg.Done()
+
+	for {
+		select {
+		case <-u.stopChan:
+			return
+
+		case <-u.WorkerChan:
+			// Worker-level updates are only rendered by the TUI
+
+		case complete := <-u.CompleteChan:
+			u.handleComplete(complete)
+
+			u.mu.Lock()
+			tradesRate, mbRate := u.throughput()
+			count := u.completedCount
+			u.mu.Unlock()
+
+			pterm.Info.Printfln("Completed %s (%d/%d): %dh, %.1fM trades | %s trades/s, %.1f MB/s",
+				complete.Month, count, u.totalMonths, complete.Hours,
+				float64(complete.Trades)/1_000_000, formatNumber(int64(tradesRate)), mbRate)
+		}
+	}
+}
+
+// throughput returns the cumulative trades/sec and MB/sec rates.
+// Caller must hold u.mu.
+func (u *UI) throughput() (tradesPerSec, mbPerSec float64) {
+	elapsed := u.clock.Since(u.startTime).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	return float64(u.totalTradesDone) / elapsed, float64(u.completedBytes) / 1024 / 1024 / elapsed
+}
+
 func (u *UI) handleWorkerUpdate(update WorkerUpdate) {
 	u.mu.Lock()
 	defer u.mu.Unlock()
@@ -170,6 +256,18 @@ func (u *UI) handleWorkerUpdate(update WorkerUpdate) {
 		} else {
 			delete(u.downloadWorkers, update.Month)
 		}
+	case WorkerExtract:
+		if update.Active {
+			u.extractWorkers[update.Month] = struct{}{}
+			if update.BytesTotal > 0 {
+				u.extractPct[update.Month] = float64(update.BytesDown) / float64(update.BytesTotal) * 100
+			} else {
+				u.extractPct[update.Month] = -1
+			}
+		} else {
+			delete(u.extractWorkers, update.Month)
+			delete(u.extractPct, update.Month)
+		}
 	case WorkerProcess:
 		if update.Active {
 			u.processWorkers[update.Month] = struct{}{}
@@ -184,6 +282,8 @@ func (u *UI) handleComplete(complete MonthComplete) {
 	defer u.mu.Unlock()
 
 	u.completedCount++
+	u.completedBytes += u.monthSizes[complete.Month]
+	u.totalTradesDone += complete.Trades
 
 	// Keep last 20 completions
 	u.recentCompletions = append(u.recentCompletions, complete)
@@ -219,13 +319,19 @@ func (u *UI) buildDisplay() string {
 	progressBar := u.makeProgressBar(pct, 40)
 	eta := u.calculateETA()
 	s += fmt.Sprintf("  Progress: %s %d/%d months (%.1f%%)\n", progressBar, u.completedCount, u.totalMonths, pct)
+	tradesRate, mbRate := u.throughput()
+	s += fmt.Sprintf("  Throughput: %s trades/s, %.1f MB/s\n", formatNumber(int64(tradesRate)), mbRate)
 	s += fmt.Sprintf("  ETA: %s\n\n", eta)
 
 	// Workers
 	s += pterm.DefaultSection.Sprint("Workers")
 	downloadList := u.getWorkerList(u.downloadWorkers)
+	extractList := u.getExtractList()
 	processList := u.getWorkerList(u.processWorkers)
 	s += fmt.Sprintf("  Download:  %d active   %s\n", len(u.downloadWorkers), downloadList)
+	if len(u.extractWorkers) > 0 {
+		s += fmt.Sprintf("  Extract:   %d active   %s\n", len(u.extractWorkers), extractList)
+	}
 	s += fmt.Sprintf("  Process:   %d active   %s\n\n", len(u.processWorkers), processList)
 
 	// Memory
@@ -274,15 +380,11 @@ func (u *UI) makeProgressBar(pct float64, width int) string {
 }
 
 func (u *UI) calculateETA() string {
-	if u.completedCount == 0 {
+	eta, ok := u.etaDuration()
+	if !ok {
 		return "calculating..."
 	}
 
-	elapsed := time.Since(u.startTime)
-	avgPerMonth := elapsed / time.Duration(u.completedCount)
-	remaining := u.totalMonths - u.completedCount
-	eta := avgPerMonth * time.Duration(remaining)
-
 	if eta < time.Minute {
 		return fmt.Sprintf("%ds", int(eta.Seconds()))
 	} else if eta < time.Hour {
@@ -291,6 +393,28 @@ func (u *UI) calculateETA() string {
 	return fmt.Sprintf("%dh %dm", int(eta.Hours()), int(eta.Minutes())%60)
 }
 
+// etaDuration estimates the remaining time. Caller must hold u.mu; ok is
+// false until at least one month has completed.
+func (u *UI) etaDuration() (time.Duration, bool) {
+	if u.completedCount == 0 {
+		return 0, false
+	}
+
+	elapsed := u.clock.Since(u.startTime)
+
+	var eta time.Duration
+	if u.totalBytes > 0 && u.completedBytes > 0 {
+		// Byte-weighted: months vary enormously in size
+		rate := float64(u.completedBytes) / elapsed.Seconds()
+		eta = time.Duration(float64(u.totalBytes-u.completedBytes) / rate * float64(time.Second))
+	} else {
+		avgPerMonth := elapsed / time.Duration(u.completedCount)
+		remaining := u.totalMonths - u.completedCount
+		eta = avgPerMonth * time.Duration(remaining)
+	}
+	return eta, true
+}
+
 func (u *UI) getWorkerList(workers map[string]struct{}) string {
 	if len(workers) == 0 {
 		return ""
@@ -314,6 +438,33 @@ func (u *UI) getWorkerList(workers map[string]struct{}) string {
 	return list
 }
 
+// getExtractList formats active extractions with their progress percentage
+func (u *UI) getExtractList() string {
+	if len(u.extractWorkers) == 0 {
+		return ""
+	}
+
+	months := make([]string, 0, len(u.extractWorkers))
+	for month := range u.extractWorkers {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	var list string
+	for i, month := range months {
+		if i >= 4 {
+			list += fmt.Sprintf(" +%d more", len(months)-4)
+			break
+		}
+		if pct, ok := u.extractPct[month]; ok && pct >= 0 {
+			list += fmt.Sprintf("[%s %.0f%%] ", month, pct)
+		} else {
+			list += fmt.Sprintf("[%s] ", month)
+		}
+	}
+	return list
+}
+
 // LogInfo logs an info message (works even with TUI disabled)
 func (u *UI) LogInfo(format string, args ...interface{}) {
 	if u.enabled {
@@ -325,6 +476,9 @@ func (u *UI) LogInfo(format string, args ...interface{}) {
 
 // LogError logs an error message
 func (u *UI) LogError(format string, args ...interface{}) {
+	u.mu.Lock()
+	u.lastError = fmt.Sprintf(format, args...)
+	u.mu.Unlock()
 	pterm.Error.Printfln(format, args...)
 }
 
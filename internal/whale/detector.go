@@ -1,6 +1,8 @@
 package whale
 
 import (
+	"math"
+	"sort"
 	"sync"
 
 	"github.com/clement/aggtrades/internal/parser"
@@ -19,6 +21,9 @@ type Detector struct {
 	// Cache for thresholds by date (avoid recalculating)
 	cacheMu        sync.RWMutex
 	thresholdCache map[string]Thresholds
+
+	// Cache for sorted window samples by date, for rank lookups
+	rankCache map[string][]float64
 }
 
 // NewDetector creates a new whale detector
@@ -26,15 +31,17 @@ func NewDetector(windowDays, samplesPerDay int) *Detector {
 	return &Detector{
 		rolling:        NewRollingPercentile(windowDays, samplesPerDay),
 		thresholdCache: make(map[string]Thresholds),
+		rankCache:      make(map[string][]float64),
 	}
 }
 
 // AddSample adds a trade quantity to the rolling percentile calculation
 func (d *Detector) AddSample(date string, qty float64) {
 	d.rolling.AddSample(date, qty)
-	// Invalidate cache for this date
+	// Invalidate caches for this date
 	d.cacheMu.Lock()
 	delete(d.thresholdCache, date)
+	delete(d.rankCache, date)
 	d.cacheMu.Unlock()
 }
 
@@ -49,6 +56,63 @@ func (d *Detector) Classify(trade parser.Trade) Classification {
 	}
 }
 
+// ClassifySize classifies an arbitrary order magnitude against the
+// thresholds for a date. The magnitude must be in the same units the
+// caller samples — raw quantity normally, price*qty when the detector
+// is fed notional.
+func (d *Detector) ClassifySize(date string, size float64) Classification {
+	thresholds := d.GetThresholds(date)
+
+	return Classification{
+		IsWhaleP99:  size >= thresholds.P99,
+		IsWhaleP999: size >= thresholds.P999,
+	}
+}
+
+// Rank returns the empirical percentile rank (0..1) of a trade quantity
+// against the rolling window for the date: the fraction of window samples
+// smaller than qty. Returns 0.5 while the window is still empty, so
+// warmup bars stay uninformative rather than extreme.
+func (d *Detector) Rank(date string, qty float64) float64 {
+	d.cacheMu.RLock()
+	samples, ok := d.rankCache[date]
+	d.cacheMu.RUnlock()
+
+	if !ok {
+		samples = d.rolling.WindowSamples(date)
+		d.cacheMu.Lock()
+		d.rankCache[date] = samples
+		d.cacheMu.Unlock()
+	}
+
+	if len(samples) == 0 {
+		return 0.5
+	}
+	return float64(sort.SearchFloat64s(samples, qty)) / float64(len(samples))
+}
+
+// TierThreshold returns the rolling quantity threshold for an arbitrary
+// percentile tier (e.g. 95 or 99.5) for a date, reusing the sorted window
+// cache the rank lookups maintain. Returns +Inf while the window is still
+// empty, so nothing is classified during warmup.
+func (d *Detector) TierThreshold(date string, pct float64) float64 {
+	d.cacheMu.RLock()
+	samples, ok := d.rankCache[date]
+	d.cacheMu.RUnlock()
+
+	if !ok {
+		samples = d.rolling.WindowSamples(date)
+		d.cacheMu.Lock()
+		d.rankCache[date] = samples
+		d.cacheMu.Unlock()
+	}
+
+	if len(samples) == 0 {
+		return math.Inf(1)
+	}
+	return percentile(samples, pct)
+}
+
 // GetThresholds returns the thresholds for a given date
 func (d *Detector) GetThresholds(date string) Thresholds {
 	// Fast path: check cache with read lock
@@ -78,13 +142,18 @@ func (d *Detector) HasEnoughData(date string) bool {
 func (d *Detector) PruneOldDates(currentDate string) {
 	d.rolling.PruneOldDates(currentDate)
 
-	// Also clean threshold cache
+	// Also clean the threshold and rank caches
 	d.cacheMu.Lock()
 	for date := range d.thresholdCache {
 		if date < currentDate {
 			delete(d.thresholdCache, date)
 		}
 	}
+	for date := range d.rankCache {
+		if date < currentDate {
+			delete(d.rankCache, date)
+		}
+	}
 	d.cacheMu.Unlock()
 }
 
@@ -144,6 +144,34 @@ func (rp *RollingPercentile) GetThresholds(date string) Thresholds {
 	}
 }
 
+// WindowSamples returns the sorted samples backing the given date's
+// window (the same selection GetThresholds uses), for empirical rank
+// lookups. Nil when the window is empty.
+func (rp *RollingPercentile) WindowSamples(date string) []float64 {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	var windowDates []string
+	for _, d := range rp.dateOrder {
+		if d >= date {
+			break
+		}
+		windowDates = append(windowDates, d)
+	}
+	if len(windowDates) > rp.windowDays {
+		windowDates = windowDates[len(windowDates)-rp.windowDays:]
+	}
+
+	var allSamples []float64
+	for _, d := range windowDates {
+		if reservoir, ok := rp.dailySamples[d]; ok {
+			allSamples = append(allSamples, reservoir.Samples()...)
+		}
+	}
+	sort.Float64s(allSamples)
+	return allSamples
+}
+
 // HasEnoughData returns true if we have at least windowDays of data before the given date
 func (rp *RollingPercentile) HasEnoughData(date string) bool {
 	rp.mu.Lock()